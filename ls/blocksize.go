@@ -0,0 +1,95 @@
+package ls
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// BlockSize is the unit used for the size column and the total line of
+// long listings, as set by --block-size.
+type BlockSize struct {
+	// Bytes is the size of one unit. Zero shows sizes in bytes and the
+	// total in 1K blocks.
+	Bytes int64
+	// Suffix is appended to every scaled value. It is set when the unit
+	// is given without a number, as in --block-size=K.
+	Suffix string
+}
+
+// ParseBlockSize parses a --block-size argument such as "1M", "K", "kB"
+// or "4096". Units are powers of 1024, or of 1000 when followed by "B".
+func ParseBlockSize(spec string) (BlockSize, error) {
+	size, suffix, err := parseSize(spec, "block-size")
+	if err != nil {
+		return BlockSize{}, err
+	}
+	if size == 0 {
+		return BlockSize{}, fmt.Errorf("invalid --block-size argument '%s'", spec)
+	}
+	return BlockSize{Bytes: size, Suffix: suffix}, nil
+}
+
+// ParseMinSize parses a --min-size argument, a number of bytes with the
+// same units as ParseBlockSize, such as "10M".
+func ParseMinSize(spec string) (int64, error) {
+	size, _, err := parseSize(spec, "min-size")
+	return size, err
+}
+
+// parseSize parses a size for the named option. When the unit is given
+// without a number it also returns the unit as GNU ls shows it.
+func parseSize(spec, option string) (int64, string, error) {
+	digits, unit := splitDigits(spec)
+	if digits == "" && unit == "" {
+		return 0, "", fmt.Errorf("invalid --%s argument '%s'", option, spec)
+	}
+
+	size := int64(1)
+	if digits != "" {
+		n, err := strconv.ParseInt(digits, 10, 64)
+		if err != nil {
+			return 0, "", fmt.Errorf("--%s argument '%s' too large", option, spec)
+		}
+		size = n
+	}
+
+	var suffix string
+	if unit != "" {
+		power := strings.IndexByte("KMGTPEZY", strings.ToUpper(unit)[0]) + 1
+		base, ok := map[string]int64{"": 1024, "B": 1000, "iB": 1024}[unit[1:]]
+		if power == 0 || !ok {
+			if digits == "" {
+				return 0, "", fmt.Errorf("invalid --%s argument '%s'", option, spec)
+			}
+			return 0, "", fmt.Errorf("invalid suffix in --%s argument '%s'", option, spec)
+		}
+		for ; power > 0; power-- {
+			if size > math.MaxInt64/base {
+				return 0, "", fmt.Errorf("--%s argument '%s' too large", option, spec)
+			}
+			size *= base
+		}
+
+		// The unit is shown the way GNU ls writes it, so "KB" becomes "kB".
+		suffix = strings.ToUpper(unit[:1]) + unit[1:]
+		if suffix == "KB" {
+			suffix = "kB"
+		}
+	}
+
+	if digits != "" {
+		suffix = ""
+	}
+	return size, suffix, nil
+}
+
+// format writes bytes in units of b, rounding up.
+func (b BlockSize) format(bytes int64) string {
+	units := bytes / b.Bytes
+	if bytes%b.Bytes != 0 {
+		units++
+	}
+	return strconv.FormatInt(units, 10) + b.Suffix
+}
@@ -0,0 +1,84 @@
+package ls
+
+import "testing"
+
+func TestParseBlockSize(t *testing.T) {
+	tests := []struct {
+		spec string
+		want BlockSize
+	}{
+		{"1", BlockSize{Bytes: 1}},
+		{"4096", BlockSize{Bytes: 4096}},
+		{"K", BlockSize{Bytes: 1024, Suffix: "K"}},
+		{"1K", BlockSize{Bytes: 1024}},
+		{"k", BlockSize{Bytes: 1024, Suffix: "K"}},
+		{"KiB", BlockSize{Bytes: 1024, Suffix: "KiB"}},
+		{"kB", BlockSize{Bytes: 1000, Suffix: "kB"}},
+		{"KB", BlockSize{Bytes: 1000, Suffix: "kB"}},
+		{"M", BlockSize{Bytes: 1 << 20, Suffix: "M"}},
+		{"2MB", BlockSize{Bytes: 2000000}},
+		{"G", BlockSize{Bytes: 1 << 30, Suffix: "G"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseBlockSize(tt.spec)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseBlockSize(%q) = %+v, %v, want %+v", tt.spec, got, err, tt.want)
+		}
+	}
+
+	errors := []struct{ spec, want string }{
+		{"", "invalid --block-size argument ''"},
+		{"0", "invalid --block-size argument '0'"},
+		{"X", "invalid --block-size argument 'X'"},
+		{"1X", "invalid suffix in --block-size argument '1X'"},
+		{"1KX", "invalid suffix in --block-size argument '1KX'"},
+		{"99999999999999999999", "--block-size argument '99999999999999999999' too large"},
+		{"Y", "--block-size argument 'Y' too large"},
+	}
+	for _, tt := range errors {
+		if _, err := ParseBlockSize(tt.spec); err == nil || err.Error() != tt.want {
+			t.Errorf("ParseBlockSize(%q) error = %v, want %q", tt.spec, err, tt.want)
+		}
+	}
+}
+
+func TestParseMinSize(t *testing.T) {
+	tests := []struct {
+		spec string
+		want int64
+	}{
+		{"0", 0},
+		{"100", 100},
+		{"1K", 1024},
+		{"10M", 10 << 20},
+		{"1kB", 1000},
+		{"K", 1024},
+	}
+	for _, tt := range tests {
+		if got, err := ParseMinSize(tt.spec); err != nil || got != tt.want {
+			t.Errorf("ParseMinSize(%q) = %d, %v, want %d", tt.spec, got, err, tt.want)
+		}
+	}
+	if _, err := ParseMinSize("10Q"); err == nil || err.Error() != "invalid suffix in --min-size argument '10Q'" {
+		t.Errorf("ParseMinSize(10Q) error = %v", err)
+	}
+}
+
+func TestBlockSizeFormat(t *testing.T) {
+	tests := []struct {
+		size  BlockSize
+		bytes int64
+		want  string
+	}{
+		{BlockSize{Bytes: 1024}, 0, "0"},
+		{BlockSize{Bytes: 1024}, 1, "1"},
+		{BlockSize{Bytes: 1024}, 1024, "1"},
+		{BlockSize{Bytes: 1024}, 1025, "2"},
+		{BlockSize{Bytes: 1 << 20, Suffix: "M"}, 3 << 20, "3M"},
+	}
+	for _, tt := range tests {
+		if got := tt.size.format(tt.bytes); got != tt.want {
+			t.Errorf("%+v.format(%d) = %q, want %q", tt.size, tt.bytes, got, tt.want)
+		}
+	}
+}
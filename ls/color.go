@@ -0,0 +1,140 @@
+package ls
+
+import (
+	"math"
+	"os"
+	"strings"
+)
+
+// ColorMode selects when names are colorized.
+type ColorMode int
+
+const (
+	ColorNever ColorMode = iota
+	ColorAuto
+	ColorAlways
+)
+
+// defaultColors holds the SGR codes used for each file type, keyed like
+// LS_COLORS and matching the dircolors defaults.
+var defaultColors = map[string]string{
+	"di": "01;34",
+	"ln": "01;36",
+	"ex": "01;32",
+	"pi": "40;33",
+	"so": "01;35",
+	"bd": "40;33;01",
+	"cd": "40;33;01",
+}
+
+func colorKey(mode os.FileMode) string {
+	switch {
+	case mode&os.ModeDir != 0:
+		return "di"
+	case mode&os.ModeSymlink != 0:
+		return "ln"
+	case mode&os.ModeNamedPipe != 0:
+		return "pi"
+	case mode&os.ModeSocket != 0:
+		return "so"
+	case mode&os.ModeCharDevice != 0:
+		return "cd"
+	case mode&os.ModeDevice != 0:
+		return "bd"
+	case mode&0111 != 0:
+		return "ex"
+	default:
+		return "fi"
+	}
+}
+
+// A palette maps file types and name suffixes to SGR codes.
+type palette struct {
+	types    map[string]string
+	suffixes []suffixColor
+}
+
+type suffixColor struct {
+	suffix string
+	code   string
+}
+
+// parsePalette reads a colon-separated LS_COLORS value such as
+// "di=01;34:*.tar=01;31". Type keys it does not mention keep their default
+// color, and malformed entries are skipped.
+func parsePalette(spec string) palette {
+	p := palette{types: make(map[string]string, len(defaultColors))}
+	for key, code := range defaultColors {
+		p.types[key] = code
+	}
+
+	for _, entry := range strings.Split(spec, ":") {
+		key, code, ok := strings.Cut(entry, "=")
+		if !ok || key == "" {
+			continue
+		}
+		if strings.HasPrefix(key, "*") {
+			p.suffixes = append(p.suffixes, suffixColor{suffix: key[1:], code: code})
+		} else {
+			p.types[key] = code
+		}
+	}
+	return p
+}
+
+// code returns the SGR code for name. Suffix patterns win over the type
+// color for regular files.
+func (p palette) code(name string, mode os.FileMode) string {
+	if mode.IsRegular() {
+		// Later entries override earlier ones, as with repeated keys.
+		for i := len(p.suffixes) - 1; i >= 0; i-- {
+			if strings.HasSuffix(name, p.suffixes[i].suffix) {
+				return p.suffixes[i].code
+			}
+		}
+	}
+	return p.types[colorKey(mode)]
+}
+
+// sizeScale holds the SGR codes used by ColorScale, each for the sizes
+// below its limit: green under 1K, then yellow, red, and bold red from
+// 1G up.
+var sizeScale = []struct {
+	limit int64
+	code  string
+}{
+	{1 << 10, "32"},
+	{1 << 20, "33"},
+	{1 << 30, "31"},
+	{math.MaxInt64, "01;31"},
+}
+
+func sizeColor(size int64) string {
+	for _, step := range sizeScale {
+		if size < step.limit {
+			return step.code
+		}
+	}
+	return sizeScale[len(sizeScale)-1].code
+}
+
+// colorText wraps text, the label shown for name, in the escape sequence
+// for the file's type when color output is enabled. Any padding in front
+// of a quoted label stays outside the escape sequence.
+func (l *Lister) colorText(text, name string, mode os.FileMode) string {
+	if !l.colorize {
+		return text
+	}
+	code := l.palette.code(name, mode)
+	if code == "" {
+		return text
+	}
+	return wrapColor(text, code)
+}
+
+// wrapColor wraps text in the escape sequence for code, leaving leading
+// padding outside it.
+func wrapColor(text, code string) string {
+	pad := text[:len(text)-len(strings.TrimLeft(text, " "))]
+	return pad + "\033[" + code + "m" + text[len(pad):] + "\033[0m"
+}
@@ -0,0 +1,66 @@
+package ls
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPaletteCode(t *testing.T) {
+	p := parsePalette("di=01;33:*.tar=01;31:*.tar=00;31:broken:=x:ex=")
+	tests := []struct {
+		name string
+		mode os.FileMode
+		want string
+	}{
+		{"dir", os.ModeDir | 0o755, "01;33"},
+		{"link", os.ModeSymlink | 0o777, "01;36"},
+		{"a.tar", 0o644, "00;31"},
+		{"a.tar", 0o755, "00;31"},
+		{"plain", 0o644, ""},
+		// An empty code turns the color off.
+		{"run", 0o755, ""},
+		{"pipe", os.ModeNamedPipe | 0o644, "40;33"},
+		{"sock", os.ModeSocket | 0o755, "01;35"},
+		{"tty", os.ModeDevice | os.ModeCharDevice | 0o620, "40;33;01"},
+		{"disk", os.ModeDevice | 0o660, "40;33;01"},
+		// Suffixes only apply to regular files.
+		{"d.tar", os.ModeDir | 0o755, "01;33"},
+	}
+	for _, tt := range tests {
+		if got := p.code(tt.name, tt.mode); got != tt.want {
+			t.Errorf("code(%q, %v) = %q, want %q", tt.name, tt.mode, got, tt.want)
+		}
+	}
+	if got := parsePalette("").code("run", 0o755); got != "01;32" {
+		t.Errorf("default executable color = %q, want 01;32", got)
+	}
+}
+
+func TestWrapColor(t *testing.T) {
+	if got, want := wrapColor(" plain", "01;34"), " \x1b[01;34mplain\x1b[0m"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestListColor(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"d/": "", "f": ""})
+	if err := os.WriteFile(filepath.Join(dir, "run"), nil, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	symlink(t, "f", filepath.Join(dir, "link"))
+
+	want := "\x1b[01;34md\x1b[0m\nf\n\x1b[01;36mlink\x1b[0m\n\x1b[01;32mrun\x1b[0m\n"
+	if got := mustList(t, &Lister{Color: ColorAlways}, dir); got != want {
+		t.Errorf("ColorAlways: got %q, want %q", got, want)
+	}
+	// Out is not a terminal, so ColorAuto leaves names plain.
+	if got := mustList(t, &Lister{Color: ColorAuto}, dir); strings.Contains(got, "\x1b") {
+		t.Errorf("ColorAuto: got %q", got)
+	}
+	out := mustList(t, &Lister{Color: ColorAlways, ColorSpec: "di=04"}, dir)
+	if !strings.HasPrefix(out, "\x1b[04md\x1b[0m\n") {
+		t.Errorf("ColorSpec: got %q", out)
+	}
+}
@@ -0,0 +1,206 @@
+package ls
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// A cell is one entry of the short format. Its text may hold escape
+// sequences, so the number of columns it occupies is kept separately.
+type cell struct {
+	text  string
+	width int
+}
+
+// nameCells builds the short-format cells for entries, showing each as its
+// quoted label with inode numbers, allocated sizes, security contexts,
+// color and type indicators added as configured.
+func (l *Lister) nameCells(entries []*entry, labels []string) []cell {
+	inodes := make([]string, len(entries))
+	blocks := make([]string, len(entries))
+	modes := make([]os.FileMode, len(entries))
+	inodeWidth, blocksWidth := 0, 0
+	if l.needsStat() {
+		for i, e := range entries {
+			// Without a stat, the type from the directory read still
+			// picks the color and indicator.
+			inodes[i], blocks[i], modes[i] = "?", "?", e.mode
+			if fileInfo, err := e.stat(); err == nil {
+				if stat, ok := sysStat(fileInfo); ok {
+					inodes[i] = strconv.FormatUint(stat.ino, 10)
+					blocks[i] = l.formatBlocks(stat.blocks)
+				}
+				modes[i] = fileInfo.Mode()
+			}
+			inodeWidth = max(inodeWidth, len(inodes[i]))
+			blocksWidth = max(blocksWidth, len(blocks[i]))
+		}
+	}
+
+	contexts := make([]string, len(entries))
+	contextWidth := 0
+	if l.SecurityContext {
+		for i, e := range entries {
+			contexts[i] = l.calls.securityContext(e.path)
+			contextWidth = max(contextWidth, len(contexts[i]))
+		}
+	}
+
+	// Names run on after each other with commas, so nothing is padded.
+	if l.format == FormatCommas {
+		inodeWidth, blocksWidth, contextWidth = 0, 0, 0
+	}
+
+	cells := make([]cell, len(entries))
+	for i, e := range entries {
+		suffix := l.indicator(modes[i])
+		cells[i] = cell{
+			text:  l.colorText(labels[i], e.name, modes[i]) + suffix,
+			width: displayWidth(labels[i]) + len(suffix),
+		}
+		if l.Icons {
+			cells[i].text = icon(e.name, modes[i]) + " " + cells[i].text
+			cells[i].width += 2
+		}
+		prefix := ""
+		if l.Inode {
+			prefix = fmt.Sprintf("%*s ", inodeWidth, inodes[i])
+		}
+		if l.AllocatedSize {
+			prefix += fmt.Sprintf("%*s ", blocksWidth, blocks[i])
+		}
+		if l.SecurityContext {
+			prefix += fmt.Sprintf("%*s ", contextWidth, contexts[i])
+		}
+		cells[i].text = prefix + cells[i].text
+		cells[i].width += len(prefix)
+	}
+	return cells
+}
+
+// printNames writes cells in columns, or one per line for
+// FormatSingleColumn.
+func (l *Lister) printNames(cells []cell) {
+	if l.format == FormatSingleColumn {
+		for _, c := range cells {
+			fmt.Fprint(l.out, c.text)
+			l.endLine()
+		}
+		return
+	}
+	l.printColumns(cells, l.width)
+}
+
+// columnGap is the number of spaces between adjacent columns.
+const columnGap = 2
+
+// minColumnWidth is the least room a column is counted as taking up.
+const minColumnWidth = 3
+
+// minPercentWidth is the narrowest line Lister.WidthPercent scales to.
+const minPercentWidth = 20
+
+// printColumns fills cells down then across, like GNU ls, or across then
+// down for FormatAcross, using as many columns as fit in width.
+func (l *Lister) printColumns(cells []cell, width int) {
+	if len(cells) == 0 {
+		return
+	}
+
+	across := l.format == FormatAcross
+	rows, colWidths := planColumns(cells, width, across)
+	for r := 0; r < rows; r++ {
+		pos := 0
+		for c, colWidth := range colWidths {
+			i, next := c*rows+r, c*rows+r+rows
+			if across {
+				i, next = r*len(colWidths)+c, r*len(colWidths)+c+1
+			}
+			if i >= len(cells) {
+				break
+			}
+			fmt.Fprint(l.out, cells[i].text)
+			if c == len(colWidths)-1 || next >= len(cells) {
+				break
+			}
+			l.indent(pos+cells[i].width, pos+colWidth+columnGap)
+			pos += colWidth + columnGap
+		}
+		l.endLine()
+	}
+}
+
+// indent pads from column from to column to, using tabs where they fit
+// when tabs are used, the way GNU ls does.
+func (l *Lister) indent(from, to int) {
+	for from < to {
+		if l.tabSize > 0 && to/l.tabSize > (from+1)/l.tabSize {
+			l.out.WriteByte('\t')
+			from += l.tabSize - from%l.tabSize
+		} else {
+			l.out.WriteByte(' ')
+			from++
+		}
+	}
+}
+
+// planColumns picks the layout with the most columns whose total width,
+// including the gaps between columns, is less than width. Like GNU ls it
+// never fills the last column of the terminal. Cells fill each column in
+// turn, or each row when across is set. It returns the number of rows and
+// the width of each column.
+func planColumns(cells []cell, width int, across bool) (int, []int) {
+	maxCols := width / minColumnWidth
+	if width%minColumnWidth != 0 {
+		maxCols++
+	}
+	for cols := min(len(cells), maxCols); cols > 1; cols-- {
+		rows := (len(cells) + cols - 1) / cols
+		used := (len(cells) + rows - 1) / rows
+		if across {
+			used = cols
+		}
+		if used < cols {
+			continue
+		}
+
+		colWidths := make([]int, used)
+		for i, cell := range cells {
+			c := i / rows
+			if across {
+				c = i % cols
+			}
+			colWidths[c] = max(colWidths[c], cell.width)
+		}
+		// As in GNU ls, every column takes up at least minColumnWidth,
+		// counting the gap after it, and a layout where no column needs
+		// more is taken even if those minimums add up to the full width.
+		total, grown := 0, false
+		for c := range colWidths {
+			room := colWidths[c]
+			if c < used-1 {
+				colWidths[c] = max(colWidths[c], minColumnWidth-columnGap)
+				room += columnGap
+			}
+			grown = grown || room > minColumnWidth
+			total += max(room, minColumnWidth)
+		}
+		if total < width || !grown {
+			return rows, colWidths
+		}
+	}
+	return len(cells), []int{0}
+}
+
+// terminalWidth returns the column count of the terminal behind w and
+// whether w is a terminal at all.
+func terminalWidth(w io.Writer) (int, bool) {
+	f, ok := w.(*os.File)
+	if !ok {
+		return 0, false
+	}
+
+	return terminalColumns(f)
+}
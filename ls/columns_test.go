@@ -0,0 +1,227 @@
+package ls
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// columnNames is laid out by GNU ls in the expected outputs below.
+var columnNames = []string{
+	"alpha", "bravo", "charlie", "delta", "echo", "foxtrot",
+	"golf", "hotel", "india", "juliett", "kilo", "lima",
+}
+
+func makeNamed(t *testing.T, names ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestColumns(t *testing.T) {
+	dir := makeNamed(t, columnNames...)
+	tests := []struct {
+		lister Lister
+		want   string
+	}{
+		{
+			Lister{Format: FormatColumns, Width: 80},
+			"alpha  charlie  echo     golf   india    kilo\n" +
+				"bravo  delta    foxtrot  hotel  juliett  lima\n",
+		},
+		{
+			Lister{Format: FormatColumns, Width: 40},
+			"alpha    delta    golf   juliett\n" +
+				"bravo    echo     hotel  kilo\n" +
+				"charlie  foxtrot  india  lima\n",
+		},
+		{
+			Lister{Format: FormatAcross, Width: 40},
+			"alpha    bravo  charlie  delta  echo\n" +
+				"foxtrot  golf   hotel    india  juliett\n" +
+				"kilo     lima\n",
+		},
+		{
+			Lister{Format: FormatColumns, Width: 40, TabSize: 4},
+			"alpha\t delta\t  golf\t juliett\n" +
+				"bravo\t echo\t  hotel  kilo\n" +
+				"charlie  foxtrot  india  lima\n",
+		},
+		{
+			Lister{Format: FormatColumns, Width: 5},
+			strings.Join(columnNames, "\n") + "\n",
+		},
+		{
+			Lister{Format: FormatColumns, Width: -1, TabSize: 8},
+			strings.Join(columnNames, "  ") + "\n",
+		},
+	}
+	for _, tt := range tests {
+		if got := mustList(t, &tt.lister, dir); got != tt.want {
+			t.Errorf("format %d, width %d, tab size %d:\ngot\n%q\nwant\n%q",
+				tt.lister.Format, tt.lister.Width, tt.lister.TabSize, got, tt.want)
+		}
+	}
+}
+
+func TestColumnsWithColorUseSpaces(t *testing.T) {
+	dir := makeNamed(t, columnNames...)
+	out := mustList(t, &Lister{Format: FormatColumns, Width: 40, TabSize: 8, Color: ColorAlways}, dir)
+	if strings.Contains(out, "\t") {
+		t.Errorf("colored columns hold tabs:\n%q", out)
+	}
+}
+
+func TestCommas(t *testing.T) {
+	dir := makeNamed(t, columnNames...)
+	want := "alpha, bravo,\ncharlie, delta,\necho, foxtrot, golf,\nhotel, india,\njuliett, kilo, lima\n"
+	if got := mustList(t, &Lister{Format: FormatCommas, Width: 20}, dir); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestPipedNamesOnePerLine(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a": "", "b": "", "c": ""})
+	if got := mustList(t, &Lister{}, dir); got != "a\nb\nc\n" {
+		t.Errorf("got %q, want one name per line", got)
+	}
+}
+
+func TestWidthFallback(t *testing.T) {
+	tests := []struct {
+		lister Lister
+		want   int
+	}{
+		{Lister{}, 80},
+		{Lister{FallbackWidth: 50}, 50},
+		{Lister{Width: 30, FallbackWidth: 50}, 30},
+		{Lister{FallbackWidth: -1}, math.MaxInt},
+	}
+	for _, tt := range tests {
+		// Out is not a terminal, so FallbackWidth stands in for its width.
+		if l, _ := preparedLister(t, &tt.lister); l.width != tt.want {
+			t.Errorf("Width %d, FallbackWidth %d: width %d, want %d",
+				tt.lister.Width, tt.lister.FallbackWidth, l.width, tt.want)
+		}
+	}
+}
+
+func TestFallbackWidth(t *testing.T) {
+	dir := makeNamed(t, columnNames...)
+	want := mustList(t, &Lister{Format: FormatColumns, Width: 40}, dir)
+	if got := mustList(t, &Lister{Format: FormatColumns, FallbackWidth: 40}, dir); got != want {
+		t.Errorf("FallbackWidth 40: got %q, want %q", got, want)
+	}
+	want = mustList(t, &Lister{Format: FormatColumns, Width: 80}, dir)
+	if got := mustList(t, &Lister{Format: FormatColumns}, dir); got != want {
+		t.Errorf("no width: got %q, want the 80-column layout %q", got, want)
+	}
+}
+
+func TestWidthPercent(t *testing.T) {
+	dir := makeNamed(t, columnNames...)
+	tests := []struct {
+		percent, fallback, width int
+	}{
+		{50, 80, 40},
+		{100, 80, 80},
+		// The width never drops below minPercentWidth or grows past
+		// the full width.
+		{1, 80, minPercentWidth},
+		{200, 40, 40},
+		{150, 10, 10},
+	}
+	for _, tt := range tests {
+		want := mustList(t, &Lister{Format: FormatColumns, Width: tt.width}, dir)
+		l := &Lister{Format: FormatColumns, FallbackWidth: tt.fallback, WidthPercent: tt.percent}
+		if got := mustList(t, l, dir); got != want {
+			t.Errorf("%d%% of %d: got %q, want width %d %q", tt.percent, tt.fallback, got, tt.width, want)
+		}
+	}
+	// An explicit width wins.
+	want := mustList(t, &Lister{Format: FormatColumns, Width: 80}, dir)
+	if got := mustList(t, &Lister{Format: FormatColumns, Width: 80, WidthPercent: 50}, dir); got != want {
+		t.Errorf("Width and WidthPercent: got %q, want %q", got, want)
+	}
+}
+
+func TestPlanColumns(t *testing.T) {
+	cells := make([]cell, 10)
+	for i := range cells {
+		cells[i] = cell{text: "x", width: 1}
+	}
+	// Each column takes up minColumnWidth, and the last column of the
+	// line is never filled.
+	tests := []struct {
+		width    int
+		across   bool
+		rows     int
+		colCount int
+	}{
+		{30, false, 1, 10},
+		{29, false, 1, 10},
+		{28, false, 1, 10},
+		{27, false, 2, 5},
+		{1, false, 10, 1},
+		{9, true, 4, 3},
+	}
+	for _, tt := range tests {
+		rows, colWidths := planColumns(cells, tt.width, tt.across)
+		if rows != tt.rows || len(colWidths) != tt.colCount {
+			t.Errorf("width %d, across %v: %d rows of %d columns, want %d of %d",
+				tt.width, tt.across, rows, len(colWidths), tt.rows, tt.colCount)
+		}
+	}
+}
+
+func TestColumnsWideCharacters(t *testing.T) {
+	dir := makeNamed(t, "日本語", "ab", "中文", "cd")
+	// Wide names take two columns per character, so the columns stay
+	// aligned however many bytes their names hold.
+	want := "ab  cd  中文  日本語\n"
+	if got := mustList(t, &Lister{Format: FormatColumns, Width: 80}, dir); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	want = "ab  中文\ncd  日本語\n"
+	if got := mustList(t, &Lister{Format: FormatColumns, Width: 18}, dir); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestColumnsLongName(t *testing.T) {
+	long := strings.Repeat("n", 200)
+	dir := makeNamed(t, "a", long, "b")
+	// A name wider than the line gets a line of its own.
+	want := "a\nb\n" + long + "\n"
+	if got := mustList(t, &Lister{Format: FormatColumns, Width: 80}, dir); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"", 0},
+		{"abc", 3},
+		{"日本", 4},
+		{"e\u0301", 1},
+		{"a\x01b", 2},
+		{"\xff", 1},
+		{"\u00ad", 1},
+		{"한", 2},
+	}
+	for _, tt := range tests {
+		if got := displayWidth(tt.s); got != tt.want {
+			t.Errorf("displayWidth(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
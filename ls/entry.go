@@ -0,0 +1,115 @@
+package ls
+
+import (
+	"os"
+	"sync"
+)
+
+// Dereference selects which symlinks are listed as the files they point
+// to.
+type Dereference int
+
+const (
+	// DereferenceDefault follows symlinks to directories given as
+	// arguments unless the format would show them as links, like GNU ls.
+	DereferenceDefault Dereference = iota
+	// DereferenceArgs follows every symlink given as an argument, as
+	// with -H.
+	DereferenceArgs
+	// DereferenceAll also follows symlinks found in directories, as
+	// with -L.
+	DereferenceAll
+	// DereferenceArgDirs follows symlinks to directories given as
+	// arguments whatever the format, as with
+	// --dereference-command-line-symlink-to-dir.
+	DereferenceArgDirs
+)
+
+// An entry is one name to be listed. Reading a directory only tells us
+// each entry's type, so the file is stat'd the first time an option needs
+// more and the result is kept for every later sort and column.
+type entry struct {
+	name string
+	path string
+	// mode holds the type bits known without a stat.
+	mode os.FileMode
+
+	// follow makes stat describe the target of a symlink rather than the
+	// link itself.
+	follow bool
+
+	info    os.FileInfo
+	err     error
+	statted bool
+	calls   *fsCalls
+}
+
+func (l *Lister) newEntry(dir, name string, mode os.FileMode) *entry {
+	// Like GNU ls, entries of "." are named without the "./" in messages.
+	path := joinPath(dir, name)
+	if dir == "." {
+		path = name
+	}
+	return &entry{name: name, path: path, mode: mode, calls: l.calls}
+}
+
+// stat returns the cached stat result for the entry, performing the call
+// on first use.
+func (e *entry) stat() (os.FileInfo, error) {
+	if !e.statted {
+		if e.follow {
+			e.info, e.err = e.calls.stat(e.path)
+		} else {
+			e.info, e.err = e.calls.lstat(e.path)
+		}
+		e.statted = true
+	}
+	return e.info, e.err
+}
+
+// statWorkers is the number of stat calls statEntries keeps in flight.
+// The calls spend their time waiting on the filesystem rather than on a
+// CPU, so the count does not follow GOMAXPROCS.
+const statWorkers = 16
+
+// minConcurrentStats is the number of entries below which statEntries
+// stats them in turn, since starting the workers would cost more than it
+// saves.
+const minConcurrentStats = 16
+
+// statEntries stats entries concurrently, so the latency of each call,
+// which dominates on network filesystems, overlaps with the others. Each
+// entry keeps its own result, so the order of the calls does not matter.
+func statEntries(entries []*entry) {
+	if len(entries) < minConcurrentStats {
+		for _, e := range entries {
+			e.stat()
+		}
+		return
+	}
+	next := make(chan *entry)
+	var wg sync.WaitGroup
+	wg.Add(statWorkers)
+	for i := 0; i < statWorkers; i++ {
+		go func() {
+			defer wg.Done()
+			for e := range next {
+				e.stat()
+			}
+		}()
+	}
+	for _, e := range entries {
+		next <- e
+	}
+	close(next)
+	wg.Wait()
+}
+
+// isLinkedDir reports whether e is a directory or a symlink to one.
+func (e *entry) isLinkedDir() bool {
+	if e.mode&os.ModeSymlink == 0 {
+		return e.mode.IsDir()
+	}
+	info, err := e.calls.stat(e.path)
+	return err == nil && info.IsDir()
+}
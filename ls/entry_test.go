@@ -0,0 +1,123 @@
+package ls
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestNewEntryPath(t *testing.T) {
+	l := &Lister{}
+	tests := []struct{ dir, name, want string }{
+		{".", "a", "a"},
+		{"d", "a", filepath.Join("d", "a")},
+		{"", "a", "a"},
+	}
+	for _, tt := range tests {
+		if got := l.newEntry(tt.dir, tt.name, 0).path; got != tt.want {
+			t.Errorf("newEntry(%q, %q).path = %q, want %q", tt.dir, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStatEntries(t *testing.T) {
+	dir := t.TempDir()
+	var calls fsCalls
+	var entries []*entry
+	for i := 0; i < 200; i++ {
+		name := "f" + strconv.Itoa(i)
+		if err := os.WriteFile(filepath.Join(dir, name), make([]byte, i), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, &entry{name: name, path: filepath.Join(dir, name), calls: &calls})
+	}
+	entries = append(entries, &entry{name: "missing", path: filepath.Join(dir, "missing"), calls: &calls})
+
+	statEntries(entries)
+	for i, e := range entries[:200] {
+		if !e.statted || e.err != nil || e.info.Size() != int64(i) {
+			t.Fatalf("%s: statted %v, err %v, info %v", e.name, e.statted, e.err, e.info)
+		}
+	}
+	if missing := entries[200]; !missing.statted || missing.err == nil {
+		t.Errorf("missing: statted %v, err %v", missing.statted, missing.err)
+	}
+	// Each entry is stat'd once, and later calls use the cached result.
+	statEntries(entries)
+	if got := calls.stats.Load(); got != 201 {
+		t.Errorf("%d stat calls, want 201", got)
+	}
+}
+
+// slowStats makes every stat call take delay, as on a network
+// filesystem, until the test ends.
+func slowStats(tb testing.TB, delay time.Duration) {
+	savedStat, savedLstat := statFile, lstatFile
+	tb.Cleanup(func() { statFile, lstatFile = savedStat, savedLstat })
+	statFile = func(path string) (os.FileInfo, error) {
+		time.Sleep(delay)
+		return savedStat(path)
+	}
+	lstatFile = func(path string) (os.FileInfo, error) {
+		time.Sleep(delay)
+		return savedLstat(path)
+	}
+}
+
+func TestStatEntriesOverlapsSlowCalls(t *testing.T) {
+	const delay = 10 * time.Millisecond
+	slowStats(t, delay)
+	entries := benchmarkEntries(t, 4*statWorkers)()
+	start := time.Now()
+	statEntries(entries)
+	// Sequential calls would take 4*statWorkers delays.
+	if elapsed := time.Since(start); elapsed >= 2*statWorkers*delay {
+		t.Errorf("stat'ing %d entries took %v", len(entries), elapsed)
+	}
+}
+
+// benchmarkEntries creates count files and returns a function giving
+// fresh, unstat'd entries for them.
+func benchmarkEntries(tb testing.TB, count int) func() []*entry {
+	dir := tb.TempDir()
+	var names []string
+	for i := 0; i < count; i++ {
+		name := "f" + strconv.Itoa(i)
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			tb.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	return func() []*entry {
+		var calls fsCalls
+		entries := make([]*entry, len(names))
+		for i, name := range names {
+			entries[i] = &entry{name: name, path: filepath.Join(dir, name), calls: &calls}
+		}
+		return entries
+	}
+}
+
+func BenchmarkStatEntries(b *testing.B) {
+	for _, delay := range []time.Duration{0, 100 * time.Microsecond} {
+		fresh := benchmarkEntries(b, 100)
+		if delay > 0 {
+			slowStats(b, delay)
+		}
+		b.Run(fmt.Sprintf("delay=%v/concurrent", delay), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				statEntries(fresh())
+			}
+		})
+		b.Run(fmt.Sprintf("delay=%v/sequential", delay), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, e := range fresh() {
+					e.stat()
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package ls
+
+import "fmt"
+
+// Format selects the layout of a listing.
+type Format int
+
+const (
+	// FormatDefault uses FormatColumns on a terminal and
+	// FormatSingleColumn otherwise, like GNU ls.
+	FormatDefault Format = iota
+	// FormatLong shows a row of details for each entry, as with -l.
+	FormatLong
+	// FormatSingleColumn writes one name per line, as with -1.
+	FormatSingleColumn
+	// FormatColumns fills columns down then across, as with -C.
+	FormatColumns
+	// FormatAcross fills rows across then down, as with -x.
+	FormatAcross
+	// FormatCommas separates names with commas, as with -m.
+	FormatCommas
+	// FormatJSON writes a JSON array with an object for each entry, as
+	// with --json. See printJSON.
+	FormatJSON
+	// FormatTree draws the contents of directories beneath them with
+	// connecting lines, as with --tree. See printTree.
+	FormatTree
+)
+
+// printCommas writes cells separated by commas, starting a new line
+// whenever the next name would not fit, like GNU ls -m.
+func (l *Lister) printCommas(cells []cell) {
+	if len(cells) == 0 {
+		return
+	}
+
+	pos := 0
+	for i, c := range cells {
+		if i > 0 {
+			if pos+c.width+2 < l.width {
+				fmt.Fprint(l.out, ", ")
+				pos += 2
+			} else {
+				fmt.Fprint(l.out, ",")
+				l.endLine()
+				pos = 0
+			}
+		}
+		fmt.Fprint(l.out, c.text)
+		pos += c.width
+	}
+	l.endLine()
+}
+
+// endLine ends a line of entries, with a NUL byte rather than a newline
+// when Zero is set.
+func (l *Lister) endLine() {
+	if l.Zero {
+		l.out.WriteByte(0)
+		return
+	}
+	l.out.WriteByte('\n')
+}
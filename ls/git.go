@@ -0,0 +1,102 @@
+package ls
+
+import (
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// A gitRepo holds the output of git status for the work tree at root,
+// keyed by paths relative to it.
+type gitRepo struct {
+	root  string
+	files map[string]string
+	// dirs holds untracked directories, which git reports as a whole
+	// rather than file by file.
+	dirs map[string]string
+}
+
+func (l *Lister) entryGitStatus(e *entry) string {
+	if !l.Git {
+		return ""
+	}
+	return l.gitStatus(e.path)
+}
+
+// gitStatus returns the two-letter git status of the file at path, such
+// as " M" or "??", or "" for a file that is unchanged or outside a
+// repository.
+func (l *Lister) gitStatus(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return ""
+	}
+	repo := l.gitRepo(filepath.Dir(abs))
+	if repo == nil {
+		return ""
+	}
+	rel, err := filepath.Rel(repo.root, abs)
+	if err != nil {
+		return ""
+	}
+	rel = filepath.ToSlash(rel)
+	if status, ok := repo.files[rel]; ok {
+		return status
+	}
+	for dir := rel; dir != "."; dir = filepath.Dir(dir) {
+		if status, ok := repo.dirs[dir]; ok {
+			return status
+		}
+	}
+	return ""
+}
+
+// gitRepo returns the repository containing dir, found by walking up to
+// a directory holding .git, or nil if there is none. Lookups are cached
+// per directory, and git status runs once per repository.
+func (l *Lister) gitRepo(dir string) *gitRepo {
+	if repo, ok := l.gitRepos[dir]; ok {
+		return repo
+	}
+
+	var repo *gitRepo
+	if _, err := l.calls.stat(filepath.Join(dir, ".git")); err == nil {
+		repo = l.readGitStatus(dir)
+	} else if parent := filepath.Dir(dir); parent != dir {
+		repo = l.gitRepo(parent)
+	}
+	l.gitRepos[dir] = repo
+	return repo
+}
+
+// readGitStatus runs git status in the work tree at root. If git fails,
+// the problem is reported and the repository shows no statuses.
+func (l *Lister) readGitStatus(root string) *gitRepo {
+	repo := &gitRepo{root: root, files: make(map[string]string), dirs: make(map[string]string)}
+	out, err := exec.Command("git", "-C", root, "status", "--porcelain", "-z").Output()
+	if err != nil {
+		l.reportError(statusMinor, "cannot read git status of %s: %v", l.quote(root), err)
+		return repo
+	}
+
+	// Each record is "XY path". Renames and copies are followed by a
+	// second record holding the old path, which is skipped.
+	records := bytes.Split(out, []byte{0})
+	for i := 0; i < len(records); i++ {
+		record := string(records[i])
+		if len(record) < 4 {
+			continue
+		}
+		status, name := record[:2], record[3:]
+		if status[0] == 'R' || status[0] == 'C' {
+			i++
+		}
+		if dir, ok := strings.CutSuffix(name, "/"); ok {
+			repo.dirs[dir] = status
+		} else {
+			repo.files[name] = status
+		}
+	}
+	return repo
+}
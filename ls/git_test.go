@@ -0,0 +1,59 @@
+package ls
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func git(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %q: %v\n%s", args, err, out)
+	}
+}
+
+func TestGitStatus(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := makeFiles(t, map[string]string{"clean": "", "changed": "", "sub/inner": ""})
+	git(t, dir, "init", "-q")
+	git(t, dir, "add", ".")
+	git(t, dir, "commit", "-qm", "initial")
+	if err := os.WriteFile(filepath.Join(dir, "changed"), []byte("edit"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "new", "deep"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "new", "deep", "file"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	l, _ := preparedLister(t, &Lister{Git: true})
+	tests := []struct{ path, want string }{
+		{"clean", ""},
+		{"changed", " M"},
+		{"sub/inner", ""},
+		// Untracked directories are reported as a whole.
+		{"new/deep/file", "??"},
+	}
+	for _, tt := range tests {
+		if got := l.gitStatus(filepath.Join(dir, filepath.FromSlash(tt.path))); got != tt.want {
+			t.Errorf("gitStatus(%s) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+	if got := l.gitStatus(filepath.Join(t.TempDir(), "outside")); got != "" {
+		t.Errorf("a file outside a repository has status %q", got)
+	}
+
+	rows := longFields(mustList(t, &Lister{Format: FormatLong, Git: true}, dir))
+	if got := rows[0][len(rows[0])-2:]; got[0] != "M" || got[1] != "changed" {
+		t.Errorf("long listing row %q, want the status before the name", rows[0])
+	}
+}
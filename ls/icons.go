@@ -0,0 +1,49 @@
+package ls
+
+import (
+	"os"
+	"strings"
+)
+
+// Icons are Nerd Font glyphs, which sit in the Unicode private use area
+// and only show up as intended in a terminal using a patched font.
+const (
+	iconDir     = "\uf07b"
+	iconFile    = "\uf15b"
+	iconSymlink = "\uf0c1"
+	iconExec    = "\uf489"
+)
+
+// extensionIcons maps common extensions to the glyph shown for them.
+var extensionIcons = map[string]string{
+	"go":   "\ue626",
+	"md":   "\uf48a",
+	"zip":  "\uf410",
+	"gz":   "\uf410",
+	"tar":  "\uf410",
+	"json": "\ue60b",
+	"sh":   "\uf489",
+	"py":   "\ue606",
+	"js":   "\ue74e",
+	"png":  "\uf1c5",
+	"jpg":  "\uf1c5",
+	"pdf":  "\uf1c1",
+}
+
+// icon returns the glyph that --icons puts before an entry called name
+// with the given mode.
+func icon(name string, mode os.FileMode) string {
+	switch {
+	case mode&os.ModeDir != 0:
+		return iconDir
+	case mode&os.ModeSymlink != 0:
+		return iconSymlink
+	}
+	if glyph, ok := extensionIcons[strings.ToLower(fileExtension(name))]; ok {
+		return glyph
+	}
+	if mode.IsRegular() && mode&0111 != 0 {
+		return iconExec
+	}
+	return iconFile
+}
@@ -0,0 +1,39 @@
+package ls
+
+import "os"
+
+// IndicatorStyle selects the suffix appended to names to show their type.
+type IndicatorStyle int
+
+const (
+	// IndicatorNone appends nothing.
+	IndicatorNone IndicatorStyle = iota
+	// IndicatorSlash marks only directories, as with -p.
+	IndicatorSlash
+	// IndicatorClassify marks directories, executables, symlinks, FIFOs
+	// and sockets, as with -F.
+	IndicatorClassify
+	// IndicatorFileType is IndicatorClassify without the mark for
+	// executables, as with --file-type.
+	IndicatorFileType
+)
+
+func (l *Lister) indicator(mode os.FileMode) string {
+	switch {
+	case l.Indicator == IndicatorNone:
+		return ""
+	case mode&os.ModeDir != 0:
+		return "/"
+	case l.Indicator == IndicatorSlash:
+		return ""
+	case mode&os.ModeSymlink != 0:
+		return "@"
+	case mode&os.ModeNamedPipe != 0:
+		return "|"
+	case mode&os.ModeSocket != 0:
+		return "="
+	case l.Indicator == IndicatorClassify && mode.IsRegular() && mode&0111 != 0:
+		return "*"
+	}
+	return ""
+}
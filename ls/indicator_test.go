@@ -0,0 +1,108 @@
+package ls
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIndicator(t *testing.T) {
+	modes := []struct {
+		name string
+		mode os.FileMode
+	}{
+		{"dir", os.ModeDir | 0o755},
+		{"file", 0o644},
+		{"exec", 0o755},
+		{"link", os.ModeSymlink | 0o777},
+		{"fifo", os.ModeNamedPipe | 0o644},
+		{"socket", os.ModeSocket | 0o755},
+	}
+	tests := []struct {
+		style IndicatorStyle
+		want  []string
+	}{
+		{IndicatorNone, []string{"", "", "", "", "", ""}},
+		{IndicatorSlash, []string{"/", "", "", "", "", ""}},
+		{IndicatorClassify, []string{"/", "", "*", "@", "|", "="}},
+		{IndicatorFileType, []string{"/", "", "", "@", "|", "="}},
+	}
+	for _, tt := range tests {
+		l := &Lister{Indicator: tt.style}
+		for i, m := range modes {
+			if got := l.indicator(m.mode); got != tt.want[i] {
+				t.Errorf("style %d, %s: got %q, want %q", tt.style, m.name, got, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestListIndicators(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"d/": "", "f": ""})
+	if err := os.WriteFile(filepath.Join(dir, "run"), nil, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	symlink(t, "d", filepath.Join(dir, "link"))
+
+	tests := []struct {
+		style IndicatorStyle
+		want  string
+	}{
+		{IndicatorSlash, "d/\nf\nlink\nrun\n"},
+		{IndicatorClassify, "d/\nf\nlink@\nrun*\n"},
+		{IndicatorFileType, "d/\nf\nlink@\nrun\n"},
+	}
+	for _, tt := range tests {
+		if got := mustList(t, &Lister{Indicator: tt.style}, dir); got != tt.want {
+			t.Errorf("style %d: got %q, want %q", tt.style, got, tt.want)
+		}
+	}
+
+	// In long listings the mark goes after the link target and describes
+	// what it points to, as in GNU ls.
+	rows := longFields(mustList(t, &Lister{Format: FormatLong, Indicator: IndicatorClassify}, dir))
+	if got := rows[2][len(rows[2])-3:]; got[0] != "link" || got[2] != "d/" {
+		t.Errorf("long -F link: got %q, want link -> d/", got)
+	}
+	rows = longFields(mustList(t, &Lister{Format: FormatLong, Indicator: IndicatorSlash}, dir))
+	if got := rows[2][len(rows[2])-1]; got != "d" {
+		t.Errorf("long -p link target: got %q, want d", got)
+	}
+	rows = longFields(mustList(t, &Lister{Format: FormatLong, Indicator: IndicatorFileType}, dir))
+	if got := rows[2][len(rows[2])-1]; got != "d/" {
+		t.Errorf("long --file-type link target: got %q, want d/", got)
+	}
+}
+
+func TestIcon(t *testing.T) {
+	tests := []struct {
+		name string
+		mode os.FileMode
+		want string
+	}{
+		{"dir", os.ModeDir | 0o755, iconDir},
+		{"dir.go", os.ModeDir | 0o755, iconDir},
+		{"link", os.ModeSymlink | 0o777, iconSymlink},
+		{"main.go", 0o644, extensionIcons["go"]},
+		{"README.MD", 0o644, extensionIcons["md"]},
+		{"run", 0o755, iconExec},
+		{"file", 0o644, iconFile},
+		{".bashrc", 0o644, iconFile},
+	}
+	for _, tt := range tests {
+		if got := icon(tt.name, tt.mode); got != tt.want {
+			t.Errorf("icon(%q, %v) = %q, want %q", tt.name, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestListIcons(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"d/": "", "main.go": ""})
+	if err := os.WriteFile(filepath.Join(dir, "run"), nil, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	want := iconDir + " d\n" + extensionIcons["go"] + " main.go\n" + iconExec + " run\n"
+	if got := mustList(t, &Lister{Icons: true}, dir); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,121 @@
+package ls
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+	"unicode/utf8"
+)
+
+// A jsonEntry is the object FormatJSON writes for each entry.
+type jsonEntry struct {
+	Name       string `json:"name"`
+	Path       string `json:"path"`
+	Size       int64  `json:"size"`
+	Mode       string `json:"mode"`
+	ModTime    string `json:"modTime"`
+	UID        int    `json:"uid"`
+	GID        int    `json:"gid"`
+	IsDir      bool   `json:"isDir"`
+	LinkTarget string `json:"linkTarget,omitempty"`
+	// JSON strings hold only valid UTF-8, so encoding/json writes other
+	// bytes as U+FFFD. For a name, path or link target that is not valid
+	// UTF-8 the exact bytes are also given in base64.
+	NameBase64       string `json:"nameBase64,omitempty"`
+	PathBase64       string `json:"pathBase64,omitempty"`
+	LinkTargetBase64 string `json:"linkTargetBase64,omitempty"`
+	// Children holds the contents of a directory that was listed. It is
+	// left out for other entries, and is empty rather than missing for an
+	// empty directory.
+	Children *[]jsonEntry `json:"children,omitempty"`
+}
+
+// printJSON writes entries, the arguments to List, as a JSON array. The
+// contents of directories are nested under them as children, and with
+// Recursive so are those of their subdirectories. Arguments are encoded
+// one at a time, so only one of them is held in memory at once.
+func (l *Lister) printJSON(entries []*entry) {
+	fmt.Fprint(l.out, "[")
+	for i, e := range entries {
+		if i > 0 {
+			fmt.Fprint(l.out, ",")
+		}
+		item := l.jsonEntry(e)
+		if item.IsDir && !l.Directory {
+			item.Children = l.jsonChildren(e.path, 1)
+		}
+		data, _ := json.Marshal(item)
+		l.out.Write(data)
+	}
+	fmt.Fprintln(l.out, "]")
+}
+
+// jsonChildren returns the JSON objects for the contents of the directory
+// path, found depth levels down from an argument. It returns nil if the
+// directory cannot be listed, which is reported as for other formats.
+func (l *Lister) jsonChildren(path string, depth int) *[]jsonEntry {
+	dir, err := l.calls.open(path)
+	if err != nil {
+		l.reportPathError(statusSerious, "cannot open directory", err)
+		return nil
+	}
+	defer dir.Close()
+
+	leave, ok := l.enterDir(dir, path)
+	if !ok {
+		return nil
+	}
+	defer leave()
+
+	entries := l.readEntries(dir, path)
+	children := make([]jsonEntry, len(entries))
+	for i, e := range entries {
+		children[i] = l.jsonEntry(e)
+	}
+	if !l.Recursive || l.MaxDepth > 0 && depth >= l.MaxDepth {
+		return &children
+	}
+	for i, e := range entries {
+		if l.descends(e) {
+			children[i].Children = l.jsonChildren(joinPath(path, e.name), depth+1)
+		}
+	}
+	return &children
+}
+
+// rawBase64 returns s in base64 if it is not valid UTF-8, and "" if it is.
+func rawBase64(s string) string {
+	if utf8.ValidString(s) {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+// jsonEntry describes e without its children. An entry that cannot be
+// stat'd, which has already been reported, only has its name, path and
+// type.
+func (l *Lister) jsonEntry(e *entry) jsonEntry {
+	item := jsonEntry{Name: e.name, Path: e.path, IsDir: e.mode.IsDir()}
+	if e.mode&os.ModeSymlink != 0 {
+		item.LinkTarget, _ = l.calls.readlink(e.path)
+	}
+	item.NameBase64 = rawBase64(item.Name)
+	item.PathBase64 = rawBase64(item.Path)
+	item.LinkTargetBase64 = rawBase64(item.LinkTarget)
+	fileInfo, err := e.stat()
+	if err != nil {
+		item.Mode = string(fileTypeChar(e.mode)) + "?????????"
+		return item
+	}
+
+	item.Size = fileInfo.Size()
+	item.Mode = getPermissions(fileInfo.Mode())
+	item.ModTime = fileInfo.ModTime().Format(time.RFC3339)
+	item.IsDir = fileInfo.IsDir()
+	if stat, ok := sysStat(fileInfo); ok {
+		item.UID, item.GID = stat.uid, stat.gid
+	}
+	return item
+}
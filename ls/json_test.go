@@ -0,0 +1,86 @@
+package ls
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func listJSON(t *testing.T, l *Lister, paths ...string) []jsonEntry {
+	t.Helper()
+	l.Format = FormatJSON
+	var items []jsonEntry
+	if err := json.Unmarshal([]byte(mustList(t, l, paths...)), &items); err != nil {
+		t.Fatal(err)
+	}
+	return items
+}
+
+func TestJSON(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"f": "hello", "sub/inner": "", "empty/": ""})
+	symlink(t, "f", filepath.Join(dir, "link"))
+
+	items := listJSON(t, &Lister{}, dir)
+	if len(items) != 1 || !items[0].IsDir || items[0].Children == nil {
+		t.Fatalf("got %+v, want the directory with its children", items)
+	}
+	children := *items[0].Children
+	if len(children) != 4 {
+		t.Fatalf("got %d children, want 4", len(children))
+	}
+	empty, f, link, sub := children[0], children[1], children[2], children[3]
+	if f.Name != "f" || f.Path != filepath.Join(dir, "f") || f.Size != 5 || f.Mode != "-rw-r--r--" || f.IsDir {
+		t.Errorf("f = %+v", f)
+	}
+	if link.LinkTarget != "f" || link.Mode[0] != 'l' {
+		t.Errorf("link = %+v", link)
+	}
+	// Without Recursive, subdirectories are not expanded.
+	if !sub.IsDir || sub.Children != nil {
+		t.Errorf("sub = %+v", sub)
+	}
+	if empty.Children != nil {
+		t.Errorf("empty = %+v", empty)
+	}
+
+	items = listJSON(t, &Lister{Recursive: true}, dir)
+	children = *items[0].Children
+	if c := children[3].Children; c == nil || len(*c) != 1 || (*c)[0].Name != "inner" {
+		t.Errorf("sub with Recursive = %+v", children[3])
+	}
+	if c := children[0].Children; c == nil || len(*c) != 0 {
+		t.Errorf("an empty directory has children %v, want an empty list", c)
+	}
+}
+
+func TestJSONNonUTF8Names(t *testing.T) {
+	dir := t.TempDir()
+	name := "caf\xe9"
+	if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+		t.Skipf("cannot create a file named %q: %v", name, err)
+	}
+	symlink(t, "x\xff", filepath.Join(dir, "link"))
+
+	children := *listJSON(t, &Lister{}, dir)[0].Children
+	decode := func(s string) string {
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	if got := decode(children[0].NameBase64); got != name {
+		t.Errorf("nameBase64 decodes to %q, want %q", got, name)
+	}
+	if got := decode(children[0].PathBase64); got != filepath.Join(dir, name) {
+		t.Errorf("pathBase64 decodes to %q", got)
+	}
+	if got := decode(children[1].LinkTargetBase64); got != "x\xff" {
+		t.Errorf("linkTargetBase64 decodes to %q", got)
+	}
+	if children[1].NameBase64 != "" {
+		t.Errorf("a valid UTF-8 name got nameBase64 %q", children[1].NameBase64)
+	}
+}
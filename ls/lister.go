@@ -0,0 +1,778 @@
+// Package ls implements the directory listing behind the my-ls-1 command
+// so it can be embedded in other programs.
+package ls
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Errors returned by List once the problems have been reported on stderr.
+// They mirror the GNU ls exit statuses 1 and 2.
+var (
+	ErrMinor   = errors.New("some entries could not be listed")
+	ErrSerious = errors.New("some paths could not be listed")
+)
+
+const (
+	statusMinor   = 1
+	statusSerious = 2
+)
+
+// Lister lists files and directories in the style of GNU ls. Each field
+// enables the option of the same name.
+type Lister struct {
+	Format        Format
+	Recursive     bool
+	All           bool
+	AlmostAll     bool
+	Reverse       bool
+	Sort          SortKey
+	HumanReadable bool
+	Inode         bool
+	Color         ColorMode
+	Indicator     IndicatorStyle
+	TimeStyle     TimeStyle
+	Time          TimeField
+	// Directory lists directory arguments themselves rather than their
+	// contents, as with -d.
+	Directory    bool
+	Quoting      QuotingStyle
+	ControlChars ControlChars
+	// NumericIDs shows owners and groups as numbers without looking up
+	// their names, as with -n.
+	NumericIDs bool
+	// NoOwner and NoGroup leave the owner and group columns out of long
+	// listings, as with -g and -o.
+	NoOwner bool
+	NoGroup bool
+	// GroupDirectoriesFirst lists directories before other entries, each
+	// group sorted on its own.
+	GroupDirectoriesFirst bool
+	// GroupDirectoriesLast lists directories after other entries instead.
+	GroupDirectoriesLast bool
+	// Ignore leaves out directory entries whose names match any of these
+	// shell patterns, as with -I. Unlike hidden files, they stay out even
+	// with All.
+	Ignore []string
+	// Hide is like Ignore, except that All and AlmostAll show the
+	// matching entries again, as with --hide.
+	Hide []string
+	// Glob expands path arguments holding shell wildcards, for callers and
+	// platforms with no shell to do it. An argument that names an existing
+	// file, or matches nothing, is listed as given.
+	Glob bool
+	// MinSize leaves out directory entries smaller than this many bytes,
+	// as with --min-size. Directories are always kept, whatever their own
+	// size, so a recursive listing still descends into them.
+	MinSize int64
+	// NewerThan and OlderThan leave out directory entries modified at or
+	// before, or at or after, the given time, as with --newer and --older.
+	// Directories are kept as with MinSize. The zero time sets no limit.
+	NewerThan time.Time
+	OlderThan time.Time
+	// Dereference selects which symlinks are followed.
+	Dereference Dereference
+	// MaxDepth limits how many levels Recursive descends, counting the
+	// directory given as level 1. Zero means no limit.
+	MaxDepth int
+	// AllocatedSize shows the space allocated to each entry, in the units
+	// of BlockSize, as with -s.
+	AllocatedSize bool
+	// SecurityContext shows the SELinux context of each entry, as with
+	// -Z. Entries without one, or platforms without SELinux, show "?".
+	SecurityContext bool
+	// Icons puts a glyph for the type of each entry before its name, as
+	// with --icons. The glyphs come from Nerd Fonts, so they need a
+	// terminal font that includes them.
+	Icons bool
+	// ColorScale colors the size column of long listings from green for
+	// small files to red for large ones, as with --color-scale. It only
+	// applies when names are colorized.
+	ColorScale bool
+	// Git adds a column to long listings with the git status of each
+	// entry inside a repository, as with --git. The status is the two
+	// letters git status --porcelain shows, and unchanged files and files
+	// outside a repository are left blank.
+	Git bool
+	// Width is the line width that columns are fitted to. Zero uses the
+	// width of the terminal and a negative value means no limit.
+	Width int
+	// FallbackWidth takes the place of the terminal width when Out is not
+	// a terminal, as the COLUMNS environment variable does for GNU ls. It
+	// follows the same rules as Width and defaults to 80.
+	FallbackWidth int
+	// WidthPercent, when set, fits columns to that percentage of the
+	// terminal or fallback width instead, down to minPercentWidth but
+	// never past the full width. It is ignored when Width is given.
+	WidthPercent int
+	// BlockSize scales sizes in long listings. HumanReadable takes
+	// precedence over it.
+	BlockSize BlockSize
+	// TabSize is the distance between tab stops. When it is positive,
+	// columns are padded with tabs as well as spaces, as GNU ls does by
+	// default with a tab size of 8. Like GNU ls, only spaces are used
+	// for color output, which some terminals garble when mixed with
+	// tabs, and when the width is unlimited.
+	TabSize int
+	// Zero ends each entry, and each total line, with a NUL byte instead
+	// of a newline, as with --zero. Directory headers still end with a
+	// newline, as in GNU ls.
+	Zero bool
+
+	// Summary ends the listing with the number of directories listed and
+	// of files and bytes they held, as with --summary. It has no effect
+	// on FormatJSON.
+	Summary bool
+	// Stats writes the number of filesystem calls made, and the time the
+	// listing took, to Err once it is done.
+	Stats bool
+
+	// ColorSpec overrides the default colors using the LS_COLORS format.
+	ColorSpec string
+
+	// Out receives the listing and Err receives warnings. They default to
+	// os.Stdout and os.Stderr.
+	Out io.Writer
+	Err io.Writer
+
+	out        *bufio.Writer
+	format     Format
+	width      int
+	isTerminal bool
+	colorize   bool
+	palette    palette
+	quoting    QuotingStyle
+	tabSize    int
+	// hideControl is set when control characters in names are shown as
+	// '?', as resolved from ControlChars.
+	hideControl bool
+	now         time.Time
+	status      int
+	// headers is set when directory listings are introduced by their
+	// name, and listedAny once anything has been written, so later
+	// listings know to start with a blank line.
+	headers   bool
+	listedAny bool
+	// active holds the directories currently being listed, so -R can
+	// tell when a directory contains itself.
+	active map[fileID]bool
+	// calls makes and counts the filesystem calls.
+	calls   *fsCalls
+	summary summary
+	// owners and groups cache the names of user and group ids, holding ""
+	// for ids without one.
+	owners map[int]string
+	groups map[int]string
+	// gitRepos caches the repository containing each directory, holding
+	// nil for directories outside one.
+	gitRepos map[string]*gitRepo
+}
+
+// A fileID identifies a file independently of the path used to reach it.
+type fileID struct {
+	dev, ino uint64
+}
+
+// List prints each path in turn, or the current directory when paths is
+// empty. Files are listed first, followed by the contents of each
+// directory. Output is buffered and flushed before List returns.
+func (l *Lister) List(paths []string) error {
+	if l.Out == nil {
+		l.Out = os.Stdout
+	}
+	if l.Err == nil {
+		l.Err = os.Stderr
+	}
+	l.out = bufio.NewWriter(l.Out)
+	l.now = time.Now()
+	l.status = 0
+	l.calls = &fsCalls{start: l.now}
+	l.summary = summary{on: l.Summary, seen: make(map[fileID]bool)}
+	l.owners = make(map[int]string)
+	l.groups = make(map[int]string)
+	l.gitRepos = make(map[string]*gitRepo)
+
+	l.width, l.isTerminal = terminalWidth(l.Out)
+	width := l.Width
+	if width == 0 && !l.isTerminal {
+		width = l.FallbackWidth
+	}
+	switch {
+	case width > 0:
+		l.width = width
+	case width < 0:
+		l.width = math.MaxInt
+	case l.width == 0:
+		l.width = 80
+	}
+	if l.Width == 0 && l.WidthPercent > 0 && l.width != math.MaxInt {
+		l.width = min(max(l.width*l.WidthPercent/100, minPercentWidth), l.width)
+	}
+	l.format = l.Format
+	if l.format == FormatDefault {
+		l.format = FormatSingleColumn
+		if l.isTerminal {
+			l.format = FormatColumns
+		}
+	}
+	l.colorize = l.Color == ColorAlways || l.Color == ColorAuto && l.isTerminal
+	if l.colorize {
+		l.palette = parsePalette(l.ColorSpec)
+	}
+	l.tabSize = l.TabSize
+	if l.colorize || l.width == math.MaxInt {
+		l.tabSize = 0
+	}
+	l.quoting = l.Quoting
+	if l.quoting == QuoteDefault {
+		l.quoting = QuoteLiteral
+		if l.isTerminal {
+			l.quoting = QuoteShellEscape
+		}
+	}
+	l.hideControl = l.ControlChars == ControlHide || l.ControlChars == ControlDefault && l.isTerminal
+
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	if l.Glob {
+		paths = expandGlobs(paths)
+	}
+
+	// Like GNU ls, symlinks to directories given as arguments are listed
+	// as directories unless the format would show them as links.
+	followArgs := l.Dereference == DereferenceArgDirs || l.Dereference == DereferenceDefault &&
+		!l.Directory && l.format != FormatLong && l.Indicator != IndicatorClassify
+
+	var files, dirs []*entry
+	for _, path := range paths {
+		var info os.FileInfo
+		var err error
+		if l.Dereference == DereferenceDefault || l.Dereference == DereferenceArgDirs {
+			info, err = l.calls.lstat(path)
+		} else {
+			info, err = l.calls.stat(path)
+		}
+		if err != nil {
+			l.reportPathError(statusSerious, "cannot access", err)
+			continue
+		}
+		// Only links to directories are followed, so their contents
+		// are listed.
+		if followArgs && info.Mode()&os.ModeSymlink != 0 {
+			if target, err := l.calls.stat(path); err == nil && target.IsDir() {
+				info = target
+			}
+		}
+		e := l.newEntry("", path, info.Mode().Type())
+		e.info, e.statted = info, true
+
+		if info.IsDir() && !l.Directory {
+			dirs = append(dirs, e)
+			continue
+		}
+		files = append(files, e)
+	}
+
+	l.sortEntries(files)
+	l.sortEntries(dirs)
+
+	l.active = make(map[fileID]bool)
+	switch l.format {
+	case FormatJSON:
+		l.printJSON(append(files, dirs...))
+	case FormatTree:
+		l.printTree(append(files, dirs...))
+		l.listedAny = len(files)+len(dirs) > 0
+	default:
+		l.printEntries(files)
+		l.summary.addEntries(files)
+
+		// With MaxDepth 1 nothing below the arguments is listed, so the
+		// output is the same as without Recursive.
+		l.headers = len(paths) > 1 || l.Recursive && l.MaxDepth != 1
+		l.listedAny = len(files) > 0
+		for _, dir := range dirs {
+			l.listDir(dir.path, 1)
+		}
+	}
+
+	if l.Summary && l.format != FormatJSON {
+		if l.listedAny {
+			fmt.Fprintln(l.out)
+		}
+		fmt.Fprintln(l.out, &l.summary)
+	}
+
+	if err := l.out.Flush(); err != nil {
+		l.reportError(statusSerious, "write error: %v", err)
+	}
+	if l.Stats {
+		fmt.Fprintf(l.Err, "my-ls-1: %v\n", l.calls)
+	}
+
+	switch l.status {
+	case statusSerious:
+		return ErrSerious
+	case statusMinor:
+		return ErrMinor
+	}
+	return nil
+}
+
+func (l *Lister) reportError(status int, format string, a ...any) {
+	// Flush first so the warning lands after the entries listed before it,
+	// as with GNU ls.
+	l.out.Flush()
+	fmt.Fprintf(l.Err, "my-ls-1: "+format+"\n", a...)
+	if status > l.status {
+		l.status = status
+	}
+}
+
+// reportPathError reports err in the GNU form "action 'path': reason".
+func (l *Lister) reportPathError(status int, action string, err error) {
+	l.reportError(status, "%s", pathErrorText(action, err))
+}
+
+func pathErrorText(action string, err error) string {
+	var pathErr *os.PathError
+	if errors.As(err, &pathErr) {
+		return fmt.Sprintf("%s '%s': %s", action, pathErr.Path, errorText(pathErr.Err))
+	}
+	return fmt.Sprintf("%s: %s", action, errorText(err))
+}
+
+// ModTime returns the modification time of the file at path, for options
+// that compare against a reference file. Its error reads like the ones
+// List reports.
+func ModTime(path string) (time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, errors.New(pathErrorText("cannot access", err))
+	}
+	return info.ModTime(), nil
+}
+
+// errorText returns the message for err. System errors are capitalized as
+// strerror does, so "file/" on a regular file reads "Not a directory" just
+// as with GNU ls.
+func errorText(err error) string {
+	text := err.Error()
+	var errno syscall.Errno
+	if errors.As(err, &errno) && text != "" {
+		return strings.ToUpper(text[:1]) + text[1:]
+	}
+	return text
+}
+
+// listDir prints the contents of the directory path, found depth levels
+// down from an argument, descending into subdirectories when listing
+// recursively. A directory that cannot be read is reported and skipped so
+// that its siblings are still listed.
+func (l *Lister) listDir(path string, depth int) {
+	dir, err := l.calls.open(path)
+	if err != nil {
+		l.reportPathError(statusSerious, "cannot open directory", err)
+		return
+	}
+	defer dir.Close()
+
+	leave, ok := l.enterDir(dir, path)
+	if !ok {
+		return
+	}
+	defer leave()
+
+	if l.headers {
+		if l.listedAny {
+			fmt.Fprintln(l.out)
+		}
+		fmt.Fprintf(l.out, "%s:\n", l.quote(path))
+	}
+	l.listedAny = true
+
+	if l.streams() {
+		l.streamEntries(dir, path)
+		return
+	}
+	entries := l.readEntries(dir, path)
+
+	if l.format == FormatLong || l.AllocatedSize {
+		fmt.Fprintf(l.out, "total %s", l.formatBlocks(totalBlocks(entries)))
+		l.endLine()
+	}
+
+	l.printEntries(entries)
+	l.summary.addDir()
+	l.summary.addEntries(entries)
+
+	if !l.Recursive || l.MaxDepth > 0 && depth >= l.MaxDepth {
+		return
+	}
+
+	for _, e := range entries {
+		if l.descends(e) {
+			l.listDir(joinPath(path, e.name), depth+1)
+		}
+	}
+}
+
+// enterDir marks the open directory path as being listed and returns a
+// func that clears the mark again. When listing recursively it reports
+// false for a directory that is already being listed, so a directory
+// that contains itself is only listed once.
+func (l *Lister) enterDir(dir *os.File, path string) (func(), bool) {
+	if !l.Recursive {
+		return func() {}, true
+	}
+	info, err := l.calls.fstat(dir)
+	if err != nil {
+		return func() {}, true
+	}
+	stat, ok := sysStat(info)
+	if !ok {
+		return func() {}, true
+	}
+	id := fileID{dev: stat.dev, ino: stat.ino}
+	if l.active[id] {
+		l.reportError(statusSerious, "%s: not listing already-listed directory", l.quote(path))
+		return nil, false
+	}
+	l.active[id] = true
+	return func() { delete(l.active, id) }, true
+}
+
+// readEntries reads the open directory path and returns the entries to
+// list, sorted.
+func (l *Lister) readEntries(dir *os.File, path string) []*entry {
+	dirEntries, err := l.calls.readDir(dir, -1)
+	if err != nil {
+		// Whatever was read before the failure is still listed.
+		l.reportPathError(statusSerious, "reading directory", err)
+	}
+
+	entries := l.prepareEntries(path, dirEntries, l.All)
+	l.sortEntries(entries)
+	return entries
+}
+
+// streamChunk is how many directory entries streamEntries reads at once.
+const streamChunk = 512
+
+// streams reports whether directories are listed as they are read rather
+// than all at once. Only an unsorted listing of one name per line that
+// needs nothing from the whole directory can be streamed, so a directory
+// of millions of entries is listed with bounded memory.
+func (l *Lister) streams() bool {
+	return l.format == FormatSingleColumn && l.sortKey() == SortNone && !l.isTerminal &&
+		!l.Recursive && !l.AllocatedSize && !l.Summary
+}
+
+// streamEntries lists the open directory path in chunks as it is read.
+func (l *Lister) streamEntries(dir *os.File, path string) {
+	for dots := l.All; ; dots = false {
+		dirEntries, err := l.calls.readDir(dir, streamChunk)
+		l.printEntries(l.prepareEntries(path, dirEntries, dots))
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			l.reportPathError(statusSerious, "reading directory", err)
+			return
+		}
+	}
+}
+
+// prepareEntries makes the entries read from the directory path, adding
+// "." and ".." first when dots is set. Entries that are not listed are
+// dropped, and the rest are stat'd if the listing needs it.
+func (l *Lister) prepareEntries(path string, dirEntries []os.DirEntry, dots bool) []*entry {
+	entries := make([]*entry, 0, len(dirEntries)+2)
+	if dots {
+		entries = append(entries, l.newEntry(path, ".", os.ModeDir), l.newEntry(path, "..", os.ModeDir))
+	}
+	for _, dirEntry := range dirEntries {
+		e := l.newEntry(path, dirEntry.Name(), dirEntry.Type())
+		e.follow = l.Dereference == DereferenceAll
+		entries = append(entries, e)
+	}
+	if !l.All && !l.AlmostAll {
+		entries = filterHidden(entries)
+		entries = filterMatching(entries, l.Hide)
+	}
+	entries = filterMatching(entries, l.Ignore)
+
+	// Entries are stat'd up front when the listing needs it, so problems
+	// are reported before the total line, as in GNU ls.
+	if l.needsStat() {
+		statEntries(entries)
+		var vanished []error
+		for _, e := range entries {
+			if _, err := e.stat(); err != nil {
+				if l.vanished(e, err) {
+					vanished = append(vanished, err)
+					continue
+				}
+				l.reportPathError(statusMinor, "cannot access", err)
+			}
+		}
+		l.reportVanished(path, vanished)
+	}
+	if l.filtersByStat() {
+		entries = l.filterByStat(entries)
+	}
+	return entries
+}
+
+// vanished reports whether err, from stat'ing e, means that e was removed
+// after the directory was read, as happens all the time in /proc. A
+// dangling symlink that is followed fails in the same way, but is still
+// there itself.
+func (l *Lister) vanished(e *entry, err error) bool {
+	if !errors.Is(err, os.ErrNotExist) && !errors.Is(err, syscall.ESTALE) {
+		return false
+	}
+	if e.follow {
+		if _, err := l.calls.lstat(e.path); err == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// reportVanished reports the entries of the directory path that vanished
+// before they could be stat'd. A single one is reported as usual, but
+// several get one line between them rather than one each.
+func (l *Lister) reportVanished(path string, errs []error) {
+	switch len(errs) {
+	case 0:
+		return
+	case 1:
+		l.reportPathError(statusMinor, "cannot access", errs[0])
+		return
+	}
+	reason := errs[0]
+	var pathErr *os.PathError
+	if errors.As(reason, &pathErr) {
+		reason = pathErr.Err
+	}
+	l.reportError(statusMinor, "cannot access %d entries in '%s': %s", len(errs), path, errorText(reason))
+}
+
+// descends reports whether a recursive listing goes into e. The type from
+// the directory read is enough to find subdirectories, so this needs no
+// extra stat call unless symlinks are followed.
+func (l *Lister) descends(e *entry) bool {
+	if e.name == "." || e.name == ".." {
+		return false
+	}
+	if e.follow {
+		info, err := e.stat()
+		return err == nil && info.IsDir()
+	}
+	return e.mode.IsDir()
+}
+
+// needsStat reports whether listing entries takes more than their names
+// and types.
+func (l *Lister) needsStat() bool {
+	switch l.sortKey() {
+	case SortTime, SortSize:
+		return true
+	}
+	// Following symlinks, only a stat tells which entries -R descends into.
+	if l.Recursive && l.Dereference == DereferenceAll || l.filtersByStat() || l.Summary {
+		return true
+	}
+	// Colors, indicators and icons need the mode to pick out executables.
+	return l.format == FormatLong || l.format == FormatJSON || l.Inode || l.AllocatedSize || l.colorize ||
+		l.Indicator != IndicatorNone || l.Icons
+}
+
+// printEntries lists entries in the configured format.
+func (l *Lister) printEntries(entries []*entry) {
+	labels := l.quoteNames(entries)
+	switch l.format {
+	case FormatCommas:
+		l.printCommas(l.nameCells(entries, labels))
+		return
+	case FormatSingleColumn, FormatColumns, FormatAcross:
+		l.printNames(l.nameCells(entries, labels))
+		return
+	}
+
+	rows := make([]fileDetails, len(entries))
+	for i, e := range entries {
+		rows[i] = l.getFileDetails(e, labels[i])
+	}
+	l.printFileDetails(rows)
+}
+
+// quoteNames quotes the name of each entry. When some of them need shell
+// quoting and the names are laid out in rows or in columns of limited
+// width, the rest get a leading space so they still line up, as in GNU ls.
+func (l *Lister) quoteNames(entries []*entry) []string {
+	labels := make([]string, len(entries))
+	padded := false
+	for i, e := range entries {
+		labels[i] = l.quote(e.name)
+		padded = padded || shellQuoted(labels[i])
+	}
+
+	aligned := l.format == FormatLong ||
+		(l.format == FormatColumns || l.format == FormatAcross) && l.width != math.MaxInt
+	if !padded || l.quoting != QuoteShell && l.quoting != QuoteShellEscape || !aligned {
+		return labels
+	}
+	for i, label := range labels {
+		if !shellQuoted(label) {
+			labels[i] = " " + label
+		}
+	}
+	return labels
+}
+
+// shellQuoted reports whether label was quoted by a shell quoting style.
+// A name that itself starts with a quote is always quoted, so a leading
+// quote is enough to tell.
+func shellQuoted(label string) bool {
+	return strings.HasPrefix(label, "'") || strings.HasPrefix(label, `"`) || strings.HasPrefix(label, "$'")
+}
+
+// expandGlobs replaces each path holding wildcards with the files it
+// matches, unless it names a file itself. Patterns that match nothing are
+// kept, so they are reported as missing the way a shell would leave them.
+func expandGlobs(paths []string) []string {
+	var expanded []string
+	for _, path := range paths {
+		matches := globPath(path)
+		if len(matches) == 0 {
+			matches = []string{path}
+		}
+		expanded = append(expanded, matches...)
+	}
+	return expanded
+}
+
+// globPath returns the files that the shell pattern path expands to. Like
+// a shell, and unlike filepath.Glob, wildcards do not match the leading
+// dot of a name.
+func globPath(path string) []string {
+	if !strings.ContainsAny(path, "*?[") {
+		return nil
+	}
+	if _, err := os.Lstat(path); err == nil {
+		return nil
+	}
+	matches, _ := filepath.Glob(path)
+
+	var visible []string
+	for _, match := range matches {
+		// filepath.Glob may clean the start of the path, so the
+		// components are paired up from the end.
+		patternParts := strings.Split(filepath.ToSlash(path), "/")
+		matchParts := strings.Split(filepath.ToSlash(match), "/")
+		hidden := false
+		for i, j := len(patternParts)-1, len(matchParts)-1; i >= 0 && j >= 0; i, j = i-1, j-1 {
+			if strings.HasPrefix(matchParts[j], ".") && !strings.HasPrefix(patternParts[i], ".") {
+				hidden = true
+			}
+		}
+		if !hidden {
+			visible = append(visible, match)
+		}
+	}
+	return visible
+}
+
+// joinPath appends name to dir with a single separator between them,
+// dropping any extra separators dir ends in. Unlike filepath.Join it
+// keeps the rest of dir as given, so the subdirectories of "." are
+// listed as "./name" just as GNU ls does. A dir made only of separators,
+// such as "/", is kept whole, and an empty dir leaves name unchanged.
+func joinPath(dir, name string) string {
+	sep := string(os.PathSeparator)
+	trimmed := strings.TrimRight(dir, sep)
+	if trimmed == "" {
+		return dir + name
+	}
+	return trimmed + sep + name
+}
+
+// filterMatching returns the entries whose names match none of patterns.
+func filterMatching(entries []*entry, patterns []string) []*entry {
+	if len(patterns) == 0 {
+		return entries
+	}
+	var kept []*entry
+	for _, e := range entries {
+		if !matchesAny(e.name, patterns) {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// matchesAny reports whether name matches one of the shell patterns. As
+// with fnmatch and FNM_PERIOD, which GNU ls uses, a leading dot must be
+// matched by a dot in the pattern, so "*" never matches hidden files.
+// Malformed patterns match nothing.
+func matchesAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(pattern, ".") {
+			continue
+		}
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// filtersByStat reports whether entries are filtered by their size or
+// modification time.
+func (l *Lister) filtersByStat() bool {
+	return l.MinSize > 0 || !l.NewerThan.IsZero() || !l.OlderThan.IsZero()
+}
+
+// filterByStat returns entries without the files outside MinSize,
+// NewerThan and OlderThan. Entries that could not be stat'd have already
+// been reported and are kept, as are directories.
+func (l *Lister) filterByStat(entries []*entry) []*entry {
+	var kept []*entry
+	for _, e := range entries {
+		info, err := e.stat()
+		switch {
+		case err != nil || info.IsDir():
+		case info.Size() < l.MinSize:
+			continue
+		case !l.NewerThan.IsZero() && !info.ModTime().After(l.NewerThan):
+			continue
+		case !l.OlderThan.IsZero() && !info.ModTime().Before(l.OlderThan):
+			continue
+		}
+		kept = append(kept, e)
+	}
+	return kept
+}
+
+// filterHidden returns the entries whose names do not start with a dot.
+func filterHidden(entries []*entry) []*entry {
+	var visible []*entry
+	for _, e := range entries {
+		if !strings.HasPrefix(e.name, ".") {
+			visible = append(visible, e)
+		}
+	}
+	return visible
+}
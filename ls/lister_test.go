@@ -0,0 +1,702 @@
+package ls
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// list runs l on paths and returns what it wrote to stdout and stderr.
+func list(t *testing.T, l *Lister, paths ...string) (string, string, error) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	l.Out, l.Err = &out, &errOut
+	err := l.List(paths)
+	return out.String(), errOut.String(), err
+}
+
+// mustList is list for listings that should succeed without warnings.
+func mustList(t *testing.T, l *Lister, paths ...string) string {
+	t.Helper()
+	out, errOut, err := list(t, l, paths...)
+	if err != nil || errOut != "" {
+		t.Fatalf("List(%q) = %v, stderr %q", paths, err, errOut)
+	}
+	return out
+}
+
+// makeFiles creates the named files under a new temporary directory and
+// returns it. Names ending in "/" are created as directories, and every
+// other name holds its value as content.
+func makeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if strings.HasSuffix(name, "/") {
+			if err := os.MkdirAll(path, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func symlink(t *testing.T, target, path string) {
+	t.Helper()
+	if err := os.Symlink(target, path); err != nil {
+		t.Skipf("cannot create symlinks: %v", err)
+	}
+}
+
+func setModTime(t *testing.T, path string, mtime time.Time) {
+	t.Helper()
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// chdir changes to dir for the rest of the test.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+// readDirOrder returns the names in dir in the order the directory holds
+// them.
+func readDirOrder(t *testing.T, dir string) []string {
+	t.Helper()
+	f, err := os.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	names, err := f.Readdirnames(-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return names
+}
+
+func lines(s string) []string {
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+func TestFilterHidden(t *testing.T) {
+	got := entryNames(filterHidden(entriesNamed(".git", "a", ".hidden", "b.")))
+	if want := []string{"a", "b."}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFilterHiddenEmptyName(t *testing.T) {
+	entries := []*entry{{name: ""}, {name: ".h"}, {name: "a"}}
+	var got []string
+	for _, e := range filterHidden(entries) {
+		got = append(got, e.name)
+	}
+	if strings.Join(got, ",") != ",a" {
+		t.Errorf("got %q, want the empty name and a", got)
+	}
+}
+
+func TestListAllHasNoDuplicates(t *testing.T) {
+	dir := makeFiles(t, map[string]string{".hidden": "", "a": "", "b/": ""})
+	got := lines(mustList(t, &Lister{All: true}, dir))
+
+	seen := make(map[string]bool)
+	for _, name := range got {
+		if seen[name] {
+			t.Errorf("%q listed twice in %q", name, got)
+		}
+		seen[name] = true
+	}
+	if want := []string{".", "..", ".hidden", "a", "b"}; strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestListAllAndAlmostAll(t *testing.T) {
+	dir := makeFiles(t, map[string]string{".hidden": "", "a": ""})
+	tests := []struct {
+		lister Lister
+		want   string
+	}{
+		{Lister{}, "a\n"},
+		{Lister{All: true}, ".\n..\n.hidden\na\n"},
+		{Lister{AlmostAll: true}, ".hidden\na\n"},
+	}
+	for _, tt := range tests {
+		if got := mustList(t, &tt.lister, dir); got != tt.want {
+			t.Errorf("All=%v AlmostAll=%v: got %q, want %q", tt.lister.All, tt.lister.AlmostAll, got, tt.want)
+		}
+	}
+}
+
+func TestListSortsByName(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"zulu": "", "yankee": "", "xray": "", "Bravo": "", "alpha": "", "_x": ""})
+	// Names compare byte by byte, as in the C locale.
+	want := "Bravo\n_x\nalpha\nxray\nyankee\nzulu\n"
+	if got := mustList(t, &Lister{}, dir); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestListMixedArguments(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"f": "", "d1/x": "", "d2/y": ""})
+	f, d1, d2 := filepath.Join(dir, "f"), filepath.Join(dir, "d1"), filepath.Join(dir, "d2")
+
+	// Files come first, then each directory under its name.
+	want := f + "\n\n" + d1 + ":\nx\n\n" + d2 + ":\ny\n"
+	if got := mustList(t, &Lister{}, d2, f, d1); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestListBrokenSymlinkStatus(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a": "", "z": ""})
+	symlink(t, "nowhere", filepath.Join(dir, "broken"))
+
+	out, errOut, err := list(t, &Lister{Format: FormatLong, Dereference: DereferenceAll}, dir)
+	if !errors.Is(err, ErrMinor) {
+		t.Errorf("err = %v, want ErrMinor", err)
+	}
+	if !strings.Contains(errOut, "cannot access '"+filepath.Join(dir, "broken")+"'") {
+		t.Errorf("stderr %q does not report the broken link", errOut)
+	}
+	got := lines(out)
+	if len(got) != 4 || !strings.HasSuffix(got[1], " a") || !strings.HasSuffix(got[3], " z") {
+		t.Errorf("the other entries are not all listed:\n%s", out)
+	}
+}
+
+func TestListMissingArgument(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a": ""})
+	// A missing argument is serious, but the others are still listed.
+	missing := filepath.Join(dir, "missing")
+	out, errOut, err := list(t, &Lister{}, missing, filepath.Join(dir, "a"))
+	if !errors.Is(err, ErrSerious) {
+		t.Errorf("err = %v, want ErrSerious", err)
+	}
+	if want := "my-ls-1: cannot access '" + missing + "': No such file or directory"; !strings.HasPrefix(errOut, want) {
+		t.Errorf("stderr = %q, want it to start with %q", errOut, want)
+	}
+	if want := filepath.Join(dir, "a") + "\n"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
+
+func TestListUnreadableDirectory(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can read a directory with mode 000")
+	}
+	dir := makeFiles(t, map[string]string{"locked/x": "", "open/y": ""})
+	locked := filepath.Join(dir, "locked")
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(locked, 0o755)
+
+	out, errOut, err := list(t, &Lister{}, locked, filepath.Join(dir, "open"))
+	if !errors.Is(err, ErrSerious) {
+		t.Errorf("err = %v, want ErrSerious", err)
+	}
+	if !strings.Contains(errOut, "cannot open directory") {
+		t.Errorf("stderr = %q", errOut)
+	}
+	if !strings.Contains(out, "\ny\n") {
+		t.Errorf("the readable directory was not listed:\n%s", out)
+	}
+}
+
+func TestListDirectoryItself(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a": "", "b": ""})
+	if got := mustList(t, &Lister{Directory: true}, dir); got != dir+"\n" {
+		t.Errorf("got %q, want only %q", got, dir)
+	}
+}
+
+func TestJoinPath(t *testing.T) {
+	sep := string(os.PathSeparator)
+	tests := []struct{ dir, name, want string }{
+		{"", "a", "a"},
+		{".", "a", "." + sep + "a"},
+		{"d", "a", "d" + sep + "a"},
+		{"d" + sep, "a", "d" + sep + "a"},
+		{"d" + sep + sep, "a", "d" + sep + "a"},
+		{sep, "a", sep + "a"},
+		{sep + sep, "a", sep + sep + "a"},
+	}
+	for _, tt := range tests {
+		if got := joinPath(tt.dir, tt.name); got != tt.want {
+			t.Errorf("joinPath(%q, %q) = %q, want %q", tt.dir, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRecursiveHeaders(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a/b/c": ""})
+	sep := string(os.PathSeparator)
+	for _, arg := range []string{dir, dir + sep, dir + sep + sep} {
+		out := mustList(t, &Lister{Recursive: true}, arg)
+		var headers []string
+		for _, line := range lines(out) {
+			if strings.HasSuffix(line, ":") {
+				headers = append(headers, line)
+			}
+		}
+		want := []string{arg + ":", dir + sep + "a:", dir + sep + "a" + sep + "b:"}
+		if strings.Join(headers, "\n") != strings.Join(want, "\n") {
+			t.Errorf("headers for %q = %q, want %q", arg, headers, want)
+		}
+	}
+}
+
+func TestRecursiveCurrentDirectory(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a/f": ""})
+	chdir(t, dir)
+	sep := string(os.PathSeparator)
+	want := ".:\na\n\n." + sep + "a:\nf\n"
+	if got := mustList(t, &Lister{Recursive: true}); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRecursiveHiddenDirectories(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"sub/.hid/x": "", "sub/y": ""})
+	hidden := joinPath(joinPath(dir, "sub"), ".hid") + ":"
+
+	out := mustList(t, &Lister{Recursive: true}, dir)
+	if strings.Contains(out, ".hid") {
+		t.Errorf("hidden directory listed without -a:\n%s", out)
+	}
+	out = mustList(t, &Lister{Recursive: true, AlmostAll: true}, dir)
+	if !strings.Contains(out, "\n"+hidden+"\nx\n") {
+		t.Errorf("hidden directory not listed with -A:\n%s", out)
+	}
+	// "." and ".." are never descended into, so -a terminates too.
+	out = mustList(t, &Lister{Recursive: true, All: true}, dir)
+	if strings.Count(out, hidden) != 1 {
+		t.Errorf("hidden directory not listed once with -a:\n%s", out)
+	}
+}
+
+func TestGroupDirectories(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a": "", "b/": "", "c": "", "d/": ""})
+	if got := mustList(t, &Lister{GroupDirectoriesFirst: true}, dir); got != "b\nd\na\nc\n" {
+		t.Errorf("directories first: got %q", got)
+	}
+	if got := mustList(t, &Lister{GroupDirectoriesLast: true}, dir); got != "a\nc\nb\nd\n" {
+		t.Errorf("directories last: got %q", got)
+	}
+	if got := mustList(t, &Lister{GroupDirectoriesFirst: true, Reverse: true}, dir); got != "d\nb\nc\na\n" {
+		t.Errorf("directories first, reversed: got %q", got)
+	}
+	// Unsorted listings keep the directory order.
+	want := strings.Join(readDirOrder(t, dir), "\n") + "\n"
+	if got := mustList(t, &Lister{GroupDirectoriesFirst: true, Sort: SortNone}, dir); got != want {
+		t.Errorf("-U: got %q, want directory order %q", got, want)
+	}
+}
+
+func TestListFileArgument(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"f": "data"})
+	path := filepath.Join(dir, "f")
+	if got := mustList(t, &Lister{}, path); got != path+"\n" {
+		t.Errorf("got %q, want %q", got, path+"\n")
+	}
+	got := lines(mustList(t, &Lister{Format: FormatLong}, path))
+	if len(got) != 1 || !strings.HasSuffix(got[0], " "+path) {
+		t.Errorf("long listing of a file = %q", got)
+	}
+	// A dangling link is listed as itself rather than reported missing.
+	link := filepath.Join(dir, "dangling")
+	symlink(t, "nowhere", link)
+	if got := mustList(t, &Lister{}, link); got != link+"\n" {
+		t.Errorf("dangling link: got %q, want %q", got, link+"\n")
+	}
+}
+
+func TestSymlinkToDirectoryArgument(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"d/inside": ""})
+	link := filepath.Join(dir, "link")
+	symlink(t, "d", link)
+
+	if got := mustList(t, &Lister{}, link); got != "inside\n" {
+		t.Errorf("default: got %q, want the directory contents", got)
+	}
+	// Unlike -F, --file-type still follows the link, as in GNU ls.
+	if got := mustList(t, &Lister{Indicator: IndicatorFileType}, link); got != "inside\n" {
+		t.Errorf("file-type: got %q, want the directory contents", got)
+	}
+	for _, l := range []*Lister{{Format: FormatLong}, {Directory: true}, {Indicator: IndicatorClassify}} {
+		if got := mustList(t, l, link); strings.Contains(got, "inside") {
+			t.Errorf("%+v: got %q, want the link itself", *l, got)
+		}
+	}
+	got := mustList(t, &Lister{Format: FormatLong, Dereference: DereferenceArgDirs}, link)
+	if !strings.Contains(got, " inside\n") {
+		t.Errorf("DereferenceArgDirs with a long format: got %q", got)
+	}
+}
+
+func TestDereferenceArgumentSize(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"big": strings.Repeat("x", 100000)})
+	link := filepath.Join(dir, "link")
+	symlink(t, "big", link)
+
+	size := func(l *Lister) string {
+		return strings.Fields(mustList(t, l, link))[4]
+	}
+	if got := size(&Lister{Format: FormatLong}); got != "3" {
+		t.Errorf("size of the link = %s, want 3", got)
+	}
+	if got := size(&Lister{Format: FormatLong, Dereference: DereferenceArgs}); got != "100000" {
+		t.Errorf("size with -H = %s, want 100000", got)
+	}
+	if got := size(&Lister{Format: FormatLong, Dereference: DereferenceAll}); got != "100000" {
+		t.Errorf("size with -L = %s, want 100000", got)
+	}
+}
+
+func TestRecursiveMaxDepthOne(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a/b/c": "", "f": ""})
+	want := mustList(t, &Lister{}, dir)
+	if got := mustList(t, &Lister{Recursive: true, MaxDepth: 1}, dir); got != want {
+		t.Errorf("-R --max-depth=1 gave %q, want %q", got, want)
+	}
+	if got := mustList(t, &Lister{Recursive: true, MaxDepth: 2}, dir); !strings.Contains(got, "b\n") || strings.Contains(got, "c\n") {
+		t.Errorf("-R --max-depth=2 gave %q", got)
+	}
+}
+
+// preparedLister returns l after a List of an empty directory, so its
+// internal state is set up for calling its methods directly.
+func preparedLister(t *testing.T, l *Lister) (*Lister, *bytes.Buffer) {
+	t.Helper()
+	mustList(t, l, t.TempDir())
+	var errOut bytes.Buffer
+	l.Err = &errOut
+	return l, &errOut
+}
+
+func TestRecursiveSymlinkLoop(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a/f": ""})
+	symlink(t, "..", filepath.Join(dir, "a", "up"))
+
+	done := make(chan struct{})
+	var errOut string
+	var err error
+	go func() {
+		defer close(done)
+		_, errOut, err = list(t, &Lister{Recursive: true, Dereference: DereferenceAll}, dir)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("listing a symlink loop did not terminate")
+	}
+	if !errors.Is(err, ErrSerious) || !strings.Contains(errOut, "not listing already-listed directory") {
+		t.Errorf("err = %v, stderr %q", err, errOut)
+	}
+}
+
+func TestRecursiveSkipsActiveDirectory(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a/f": ""})
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// dir is marked as being listed already, as when a bind mount leads
+	// back to it.
+	stat, ok := sysStat(info)
+	if !ok {
+		t.Skip("no file IDs on this platform")
+	}
+	l, errOut := preparedLister(t, &Lister{Recursive: true})
+	l.active[fileID{dev: stat.dev, ino: stat.ino}] = true
+	l.listDir(dir, 1)
+	if !strings.Contains(errOut.String(), "not listing already-listed directory") {
+		t.Errorf("stderr = %q", errOut.String())
+	}
+	if l.status != statusSerious {
+		t.Errorf("status %d, want %d", l.status, statusSerious)
+	}
+}
+
+func TestListEndsWithNewline(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a": "", "b": ""})
+	for _, l := range []*Lister{{}, {Format: FormatSingleColumn}, {Format: FormatLong}, {Recursive: true}} {
+		out := mustList(t, l, dir)
+		if !strings.HasSuffix(out, "b\n") {
+			t.Errorf("%+v: output %q does not end with the last name and a newline", *l, out)
+		}
+	}
+	// Output written before and after a problem is all flushed.
+	out, _, err := list(t, &Lister{}, filepath.Join(dir, "missing"), dir)
+	if err == nil || out != dir+":\na\nb\n" {
+		t.Errorf("with a missing argument: got %q, %v", out, err)
+	}
+}
+
+func TestZeroTerminators(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a": "", "b c": ""})
+	out := mustList(t, &Lister{Zero: true, Format: FormatSingleColumn}, dir)
+	if out != "a\x00b c\x00" {
+		t.Errorf("got %q", out)
+	}
+	if strings.Contains(out, "\n") {
+		t.Error("output holds a newline")
+	}
+}
+
+func TestTrailingSlashOnFile(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"f": ""})
+	path := filepath.Join(dir, "f") + "/"
+	out, errOut, err := list(t, &Lister{}, path)
+	if !errors.Is(err, ErrSerious) || out != "" {
+		t.Errorf("err = %v, stdout %q", err, out)
+	}
+	if want := "cannot access '" + path + "': Not a directory"; !strings.Contains(errOut, want) {
+		t.Errorf("stderr = %q, want %q", errOut, want)
+	}
+}
+
+func TestSingleColumn(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a": "", "b": "", "c": ""})
+	if got := mustList(t, &Lister{Format: FormatSingleColumn, Width: 200}, dir); got != "a\nb\nc\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestIgnoreAndHide(t *testing.T) {
+	dir := makeFiles(t, map[string]string{
+		"a.tmp": "", "keep.go": "", "backup1": "", "backup.old": "", "app.log": "", ".x.tmp": "",
+	})
+	tests := []struct {
+		lister Lister
+		want   string
+	}{
+		{Lister{Ignore: []string{"*.tmp"}}, "app.log\nbackup.old\nbackup1\nkeep.go\n"},
+		{Lister{Ignore: []string{"backup*"}}, "a.tmp\napp.log\nkeep.go\n"},
+		// As with fnmatch and FNM_PERIOD, "*" does not match a leading dot.
+		{Lister{Ignore: []string{"*.tmp", "backup*"}, AlmostAll: true}, ".x.tmp\napp.log\nkeep.go\n"},
+		{Lister{Hide: []string{"*.log"}}, "a.tmp\nbackup.old\nbackup1\nkeep.go\n"},
+		{Lister{Hide: []string{"*.log"}, AlmostAll: true}, ".x.tmp\na.tmp\napp.log\nbackup.old\nbackup1\nkeep.go\n"},
+	}
+	for _, tt := range tests {
+		if got := mustList(t, &tt.lister, dir); got != tt.want {
+			t.Errorf("Ignore=%q Hide=%q: got %q, want %q", tt.lister.Ignore, tt.lister.Hide, got, tt.want)
+		}
+	}
+	// Patterns do not apply to the arguments themselves.
+	if got := mustList(t, &Lister{Ignore: []string{"*.tmp"}}, filepath.Join(dir, "a.tmp")); !strings.HasSuffix(got, "a.tmp\n") {
+		t.Errorf("argument: got %q", got)
+	}
+}
+
+func TestGlobArguments(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a.txt": "", "b.txt": "", "c.go": "", ".d.txt": ""})
+	chdir(t, dir)
+	if got := mustList(t, &Lister{Glob: true}, "*.txt"); got != "a.txt\nb.txt\n" {
+		t.Errorf("got %q", got)
+	}
+	// A pattern that matches nothing is reported like a missing file.
+	if _, errOut, err := list(t, &Lister{Glob: true}, "*.rs"); !errors.Is(err, ErrSerious) || !strings.Contains(errOut, "'*.rs'") {
+		t.Errorf("err = %v, stderr %q", err, errOut)
+	}
+}
+
+func TestUnsortedKeepsArgumentOrder(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a": "", "b": "", "c": "", "y/": "", "z/": ""})
+	chdir(t, dir)
+	if got := mustList(t, &Lister{Sort: SortNone}, "c", "b", "a"); got != "c\nb\na\n" {
+		t.Errorf("got %q, want c b a", got)
+	}
+	// Files still come before directories, each in the given order.
+	if got := mustList(t, &Lister{Sort: SortNone}, "z", "c", "y", "a"); got != "c\na\n\nz:\n\ny:\n" {
+		t.Errorf("mixed: got %q", got)
+	}
+}
+
+func TestUnsortedAll(t *testing.T) {
+	dir := makeFiles(t, map[string]string{".dot": "", "c": "", "a": "", "b": ""})
+	want := append([]string{".", ".."}, readDirOrder(t, dir)...)
+	got := lines(mustList(t, &Lister{All: true, Sort: SortNone}, dir))
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("got %q, want directory order %q", got, want)
+	}
+}
+
+func TestMinSize(t *testing.T) {
+	dir := makeFiles(t, map[string]string{
+		"small": strings.Repeat("x", 1023), "exact": strings.Repeat("x", 1024), "big": strings.Repeat("x", 4096), "d/": "",
+	})
+	if got := mustList(t, &Lister{MinSize: 1024}, dir); got != "big\nd\nexact\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestNewerAndOlder(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"new": "", "hour": "", "day": ""})
+	now := time.Now()
+	setModTime(t, filepath.Join(dir, "new"), now.Add(-time.Minute))
+	setModTime(t, filepath.Join(dir, "hour"), now.Add(-2*time.Hour))
+	setModTime(t, filepath.Join(dir, "day"), now.Add(-48*time.Hour))
+
+	if got := mustList(t, &Lister{NewerThan: now.Add(-time.Hour)}, dir); got != "new\n" {
+		t.Errorf("newer than an hour: got %q", got)
+	}
+	if got := mustList(t, &Lister{OlderThan: now.Add(-time.Hour)}, dir); got != "day\nhour\n" {
+		t.Errorf("older than an hour: got %q", got)
+	}
+	if got := mustList(t, &Lister{NewerThan: now.Add(-24 * time.Hour), OlderThan: now.Add(-time.Hour)}, dir); got != "hour\n" {
+		t.Errorf("between: got %q", got)
+	}
+}
+
+func TestVanishedEntries(t *testing.T) {
+	for _, vanish := range []int{1, 3} {
+		dir := makeFiles(t, map[string]string{"a": "", "b": "", "c": "", "keep": ""})
+		l, errOut := preparedLister(t, &Lister{Format: FormatLong})
+
+		f, err := os.Open(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dirEntries, err := f.ReadDir(-1)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The files are removed after the directory was read, as
+		// happens to short-lived processes in /proc.
+		for _, name := range []string{"a", "b", "c"}[:vanish] {
+			os.Remove(filepath.Join(dir, name))
+		}
+
+		entries := l.prepareEntries(dir, dirEntries, false)
+		if len(entries) != 4 {
+			t.Errorf("%d vanished: %d entries, want all 4 kept", vanish, len(entries))
+		}
+		warnings := lines(errOut.String())
+		if len(warnings) != 1 {
+			t.Fatalf("%d vanished: warnings %q, want one line", vanish, warnings)
+		}
+		want := "cannot access '" + filepath.Join(dir, "a") + "'"
+		if vanish > 1 {
+			want = "cannot access 3 entries in '" + dir + "'"
+		}
+		if !strings.Contains(warnings[0], want) {
+			t.Errorf("%d vanished: warning %q, want %q", vanish, warnings[0], want)
+		}
+		if l.status != statusMinor {
+			t.Errorf("%d vanished: status %d, want %d", vanish, l.status, statusMinor)
+		}
+	}
+}
+
+func TestListIsRepeatable(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a": "", "b/c": ""})
+	l := &Lister{Recursive: true, Summary: true}
+	first := mustList(t, l, dir)
+	if second := mustList(t, l, dir); second != first {
+		t.Errorf("second List gave %q, first %q", second, first)
+	}
+}
+
+func TestStreamedListing(t *testing.T) {
+	dir := t.TempDir()
+	const count = 3*streamChunk + 10
+	for i := 0; i < count; i++ {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%04d", i)), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l := &Lister{Sort: SortNone}
+	got := lines(mustList(t, l, dir))
+	if !l.streams() {
+		t.Error("an unsorted single-column listing is not streamed")
+	}
+	if want := readDirOrder(t, dir); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("streamed %d names, want the %d in directory order", len(got), len(want))
+	}
+}
+
+// heapSampler is an Out that records the most heap in use when anything
+// is written. It collects garbage first, so only what the listing still
+// holds is counted.
+type heapSampler struct {
+	peak uint64
+}
+
+func (h *heapSampler) Write(p []byte) (int, error) {
+	h.peak = max(h.peak, heapInUse())
+	return len(p), nil
+}
+
+func heapInUse() uint64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+// BenchmarkStreamedListing lists directories of growing size unsorted,
+// which streams them, and sorted, which holds every entry. Alongside the
+// allocations it reports the peak heap of one listing, which stays flat
+// for the streamed one.
+func BenchmarkStreamedListing(b *testing.B) {
+	for _, count := range []int{1000, 10000, 100000} {
+		dir := b.TempDir()
+		for i := 0; i < count; i++ {
+			if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%06d", i)), nil, 0o644); err != nil {
+				b.Fatal(err)
+			}
+		}
+		for _, sort := range []SortKey{SortNone, SortDefault} {
+			name := fmt.Sprintf("entries=%d/streamed", count)
+			if sort != SortNone {
+				name = fmt.Sprintf("entries=%d/sorted", count)
+			}
+			b.Run(name, func(b *testing.B) {
+				sampler := &heapSampler{}
+				base := heapInUse()
+				if err := (&Lister{Sort: sort, Out: sampler, Err: io.Discard}).List([]string{dir}); err != nil {
+					b.Fatal(err)
+				}
+				l := &Lister{Sort: sort, Out: io.Discard, Err: io.Discard}
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if err := l.List([]string{dir}); err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.ReportMetric(float64(sampler.peak-min(base, sampler.peak)), "peak-heap-B")
+			})
+		}
+	}
+}
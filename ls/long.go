@@ -0,0 +1,417 @@
+package ls
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"os/user"
+	"strconv"
+	"time"
+)
+
+// totalBlocks sums the space allocated to entries in 512-byte units.
+func totalBlocks(entries []*entry) int64 {
+	var blocks int64
+	for _, e := range entries {
+		fileInfo, err := e.stat()
+		if err != nil {
+			continue
+		}
+		stat, _ := sysStat(fileInfo)
+		blocks += stat.blocks
+	}
+	return blocks
+}
+
+// formatBlocks formats allocated space, counted in 512-byte units, for the
+// total line and -s. By default it counts 1K blocks, rounding up like GNU
+// ls.
+func (l *Lister) formatBlocks(blocks int64) string {
+	switch {
+	case l.HumanReadable:
+		return humanSize(blocks * 512)
+	case l.BlockSize.Bytes > 0:
+		return l.BlockSize.format(blocks * 512)
+	}
+	return strconv.FormatInt((blocks+1)/2, 10)
+}
+
+type fileDetails struct {
+	inode       string
+	blocks      string
+	permissions string
+	acl         bool
+	links       string
+	owner       string
+	group       string
+	// numericOwner and numericGroup are set when the owner or group is
+	// shown as a number, which GNU ls right-aligns.
+	numericOwner, numericGroup bool
+	context                    string
+	size                       string
+	// sizeCode colors the size for ColorScale.
+	sizeCode string
+	// device is set for device files, whose size column shows their
+	// major and minor numbers instead.
+	device       bool
+	major, minor string
+	modTime      string
+	// git is the git status of the entry, shown with Lister.Git.
+	git  string
+	name string
+}
+
+// getFileDetails builds the long-format row for e, showing it as the
+// already quoted label. An entry that cannot be stat'd is shown with "?"
+// in place of what is unknown, as GNU ls does.
+func (l *Lister) getFileDetails(e *entry, label string) fileDetails {
+	fileInfo, err := e.stat()
+	if err != nil {
+		return fileDetails{
+			inode:       "?",
+			blocks:      "?",
+			permissions: string(fileTypeChar(e.mode)) + "?????????",
+			links:       "?",
+			owner:       "?",
+			group:       "?",
+			context:     "?",
+			size:        "?",
+			modTime:     "?",
+			git:         l.entryGitStatus(e),
+			name:        l.colorText(label, e.name, e.mode),
+		}
+	}
+
+	mode := fileInfo.Mode()
+	size := strconv.FormatInt(fileInfo.Size(), 10)
+	if l.HumanReadable {
+		size = humanSize(fileInfo.Size())
+	} else if l.BlockSize.Bytes > 0 {
+		size = l.BlockSize.format(fileInfo.Size())
+	}
+	modTime := l.formatTime(statTime(fileInfo, l.Time))
+
+	name := l.colorText(label, e.name, mode)
+	if l.Icons {
+		name = icon(e.name, mode) + " " + name
+	}
+	if mode&os.ModeSymlink != 0 {
+		target, err := l.calls.readlink(e.path)
+		if err != nil {
+			l.reportPathError(statusMinor, "cannot read symbolic link", err)
+		} else {
+			name += " -> " + l.quote(target)
+		}
+		// Like GNU ls, the indicator describes what the link points to,
+		// and -p leaves the target unmarked.
+		if l.Indicator == IndicatorClassify || l.Indicator == IndicatorFileType {
+			if targetInfo, err := l.calls.stat(e.path); err == nil {
+				name += l.indicator(targetInfo.Mode())
+			}
+		}
+	} else {
+		name += l.indicator(mode)
+	}
+
+	// Where the platform has no stat fields, such as on Windows, the
+	// columns that need them show "?" as GNU ls does for unknown values.
+	inode, blocks, links, owner, group := "?", "?", "?", "?", "?"
+	numericOwner, numericGroup := false, false
+	device := mode&os.ModeDevice != 0
+	major, minor := "?", "?"
+	if stat, ok := sysStat(fileInfo); ok {
+		inode = strconv.FormatUint(stat.ino, 10)
+		blocks = l.formatBlocks(stat.blocks)
+		links = strconv.FormatUint(stat.nlink, 10)
+		owner, group = strconv.Itoa(stat.uid), strconv.Itoa(stat.gid)
+		numericOwner, numericGroup = true, true
+		if !l.NumericIDs {
+			owner, numericOwner = l.getOwner(stat.uid)
+			group, numericGroup = l.getGroup(stat.gid)
+		}
+		major = strconv.FormatUint(uint64(stat.major), 10)
+		minor = strconv.FormatUint(uint64(stat.minor), 10)
+	}
+
+	sizeCode := ""
+	if l.ColorScale && l.colorize && !device {
+		sizeCode = sizeColor(fileInfo.Size())
+	}
+
+	context := ""
+	if l.SecurityContext {
+		context = l.calls.securityContext(e.path)
+	}
+
+	return fileDetails{
+		inode:        inode,
+		blocks:       blocks,
+		permissions:  getPermissions(mode),
+		acl:          mode&os.ModeSymlink == 0 && l.calls.hasACL(e.path),
+		links:        links,
+		owner:        owner,
+		group:        group,
+		numericOwner: numericOwner,
+		numericGroup: numericGroup,
+		context:      context,
+		size:         size,
+		sizeCode:     sizeCode,
+		git:          l.entryGitStatus(e),
+		device:       device,
+		major:        major,
+		minor:        minor,
+		modTime:      modTime,
+		name:         name,
+	}
+}
+
+// printFileDetails writes rows in long format. Every row must be known up
+// front so numeric columns can be right-aligned and text columns padded to
+// the widest value.
+func (l *Lister) printFileDetails(rows []fileDetails) {
+	var inodeWidth, blocksWidth, linksWidth, ownerWidth, groupWidth, contextWidth, sizeWidth, timeWidth int
+	var majorWidth, minorWidth int
+	// Like GNU ls, a "?" for an unknown time is padded to the width of a
+	// real one even when no row has a time.
+	timeWidth = len(l.formatTime(l.now))
+	anyACL := false
+	for _, row := range rows {
+		anyACL = anyACL || row.acl
+		inodeWidth = max(inodeWidth, len(row.inode))
+		blocksWidth = max(blocksWidth, len(row.blocks))
+		linksWidth = max(linksWidth, len(row.links))
+		ownerWidth = max(ownerWidth, len(row.owner))
+		groupWidth = max(groupWidth, len(row.group))
+		contextWidth = max(contextWidth, len(row.context))
+		timeWidth = max(timeWidth, len(row.modTime))
+		if row.device {
+			majorWidth = max(majorWidth, len(row.major))
+			minorWidth = max(minorWidth, len(row.minor))
+		} else {
+			sizeWidth = max(sizeWidth, len(row.size))
+		}
+	}
+	// As in GNU ls, device numbers are aligned on the comma between them.
+	if majorWidth > 0 {
+		sizeWidth = max(sizeWidth, majorWidth+2+minorWidth)
+	}
+
+	for _, row := range rows {
+		if l.Inode {
+			fmt.Fprintf(l.out, "%*s ", inodeWidth, row.inode)
+		}
+		if l.AllocatedSize {
+			fmt.Fprintf(l.out, "%*s ", blocksWidth, row.blocks)
+		}
+		// Like GNU ls, a "+" marks files with an ACL, and the other rows
+		// are padded to keep the columns aligned.
+		permissions := row.permissions
+		if row.acl {
+			permissions += "+"
+		} else if anyACL {
+			permissions += " "
+		}
+		fmt.Fprintf(l.out, "%s %*s ", permissions, linksWidth, row.links)
+		if !l.NoOwner {
+			fmt.Fprintf(l.out, idFormat(row.numericOwner), ownerWidth, row.owner)
+		}
+		if !l.NoGroup {
+			fmt.Fprintf(l.out, idFormat(row.numericGroup), groupWidth, row.group)
+		}
+		if l.SecurityContext {
+			fmt.Fprintf(l.out, "%-*s ", contextWidth, row.context)
+		}
+		size := fmt.Sprintf("%*s", sizeWidth, row.size)
+		if row.device {
+			size = fmt.Sprintf("%*s, %*s", sizeWidth-2-minorWidth, row.major, minorWidth, row.minor)
+		} else if row.sizeCode != "" {
+			size = wrapColor(size, row.sizeCode)
+		}
+		fmt.Fprintf(l.out, "%s %*s ", size, timeWidth, row.modTime)
+		if l.Git {
+			fmt.Fprintf(l.out, "%2s ", row.git)
+		}
+		fmt.Fprint(l.out, row.name)
+		l.endLine()
+	}
+}
+
+// TimeStyle selects how timestamps are shown in long listings.
+type TimeStyle int
+
+const (
+	// TimeStyleLocale shows "Jan  2 15:04" for recent files and
+	// "Jan  2  2006" for files older than six months or in the future.
+	TimeStyleLocale TimeStyle = iota
+	// TimeStyleFullISO shows "2006-01-02 15:04:05.000000000 -0700".
+	TimeStyleFullISO
+	// TimeStyleLongISO shows "2006-01-02 15:04".
+	TimeStyleLongISO
+	// TimeStyleISO shows "01-02 15:04" for recent files and "2006-01-02 "
+	// otherwise.
+	TimeStyleISO
+)
+
+// sixMonths is half of an average Gregorian year, which is what GNU ls uses
+// to decide whether a timestamp is recent.
+const sixMonths = 31556952 * time.Second / 2
+
+func (l *Lister) formatTime(t time.Time) string {
+	recent := t.After(l.now.Add(-sixMonths)) && !t.After(l.now)
+	switch l.TimeStyle {
+	case TimeStyleFullISO:
+		return t.Format("2006-01-02 15:04:05.000000000 -0700")
+	case TimeStyleLongISO:
+		return t.Format("2006-01-02 15:04")
+	case TimeStyleISO:
+		if recent {
+			return t.Format("01-02 15:04")
+		}
+		return t.Format("2006-01-02 ")
+	}
+	if recent {
+		return t.Format("Jan _2 15:04")
+	}
+	return t.Format("Jan _2  2006")
+}
+
+// humanSize formats size in powers of 1024, rounding up like GNU ls -h.
+func humanSize(size int64) string {
+	if size < 1024 {
+		return strconv.FormatInt(size, 10)
+	}
+
+	const units = "KMGTPE"
+	value := float64(size)
+	unit := -1
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+
+	if value < 10 {
+		value = math.Ceil(value*10) / 10
+		if value < 10 {
+			return fmt.Sprintf("%.1f%c", value, units[unit])
+		}
+	}
+	value = math.Ceil(value)
+	if value >= 1024 && unit < len(units)-1 {
+		return fmt.Sprintf("1.0%c", units[unit+1])
+	}
+	return fmt.Sprintf("%.0f%c", value, units[unit])
+}
+
+// getPermissions renders mode as the ten characters of a long listing,
+// such as "drwxr-xr-x".
+func getPermissions(mode os.FileMode) string {
+	perms := []byte("----------")
+	perms[0] = fileTypeChar(mode)
+
+	// Each triad is read, write and execute, with the special bit that
+	// takes over its execute slot.
+	triads := []struct {
+		shift   uint
+		special os.FileMode
+		char    byte
+	}{
+		{6, os.ModeSetuid, 's'},
+		{3, os.ModeSetgid, 's'},
+		{0, os.ModeSticky, 't'},
+	}
+	for i, t := range triads {
+		bits := mode >> t.shift
+		if bits&04 != 0 {
+			perms[1+3*i] = 'r'
+		}
+		if bits&02 != 0 {
+			perms[2+3*i] = 'w'
+		}
+		perms[3+3*i] = execChar(bits&01 != 0, mode&t.special != 0, t.char)
+	}
+	return string(perms)
+}
+
+// execChar picks the character for the execute slot of a permission triad.
+// When the triad's special bit is set it shows special, upper-cased if the
+// execute bit itself is clear.
+func execChar(exec, special bool, specialChar byte) byte {
+	switch {
+	case special && exec:
+		return specialChar
+	case special:
+		return specialChar - 'a' + 'A'
+	case exec:
+		return 'x'
+	default:
+		return '-'
+	}
+}
+
+func fileTypeChar(mode os.FileMode) byte {
+	switch {
+	case mode&os.ModeDir != 0:
+		return 'd'
+	case mode&os.ModeSymlink != 0:
+		return 'l'
+	case mode&os.ModeNamedPipe != 0:
+		return 'p'
+	case mode&os.ModeSocket != 0:
+		return 's'
+	case mode&os.ModeCharDevice != 0:
+		return 'c'
+	case mode&os.ModeDevice != 0:
+		return 'b'
+	default:
+		return '-'
+	}
+}
+
+// idFormat returns the verb for an owner or group column. Like GNU ls,
+// names are left-aligned and numbers right-aligned.
+func idFormat(numeric bool) string {
+	if numeric {
+		return "%*s "
+	}
+	return "%-*s "
+}
+
+// lookupUser and lookupGroup resolve ids through the system's name
+// services. Tests replace them to count the lookups.
+var (
+	lookupUser  = user.LookupId
+	lookupGroup = user.LookupGroupId
+)
+
+// getOwner returns the name of the user uid, or uid as a number and true
+// when it has none. Each uid is looked up at most once per List, since
+// lookups may go through slow name services and most entries of a
+// directory tend to share an owner.
+func (l *Lister) getOwner(uid int) (string, bool) {
+	name, ok := l.owners[uid]
+	if !ok {
+		if user, err := lookupUser(strconv.Itoa(uid)); err == nil {
+			name = user.Username
+		}
+		l.owners[uid] = name
+	}
+	if name == "" {
+		return strconv.Itoa(uid), true
+	}
+	return name, false
+}
+
+// getGroup is getOwner for the group gid.
+func (l *Lister) getGroup(gid int) (string, bool) {
+	name, ok := l.groups[gid]
+	if !ok {
+		if group, err := lookupGroup(strconv.Itoa(gid)); err == nil {
+			name = group.Name
+		}
+		l.groups[gid] = name
+	}
+	if name == "" {
+		return strconv.Itoa(gid), true
+	}
+	return name, false
+}
@@ -0,0 +1,618 @@
+package ls
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// longFields splits each line of a long listing into its fields.
+func longFields(out string) [][]string {
+	var rows [][]string
+	for _, line := range lines(out) {
+		if strings.HasPrefix(line, "total ") {
+			continue
+		}
+		rows = append(rows, strings.Fields(line))
+	}
+	return rows
+}
+
+func TestHumanSize(t *testing.T) {
+	tests := []struct {
+		size int64
+		want string
+	}{
+		{0, "0"},
+		{1, "1"},
+		{1023, "1023"},
+		{1024, "1.0K"},
+		{1025, "1.1K"},
+		{1536, "1.5K"},
+		{10 * 1024, "10K"},
+		{10*1024 + 1, "11K"},
+		{1024*1024 - 1, "1.0M"},
+		{1 << 20, "1.0M"},
+		{5 << 30, "5.0G"},
+	}
+	for _, tt := range tests {
+		if got := humanSize(tt.size); got != tt.want {
+			t.Errorf("humanSize(%d) = %q, want %q", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestGetPermissions(t *testing.T) {
+	tests := []struct {
+		mode os.FileMode
+		want string
+	}{
+		{0o644, "-rw-r--r--"},
+		{0o755, "-rwxr-xr-x"},
+		{0, "----------"},
+		{os.ModeDir | 0o755, "drwxr-xr-x"},
+		{os.ModeDir | os.ModeSticky | 0o777, "drwxrwxrwt"},
+		{os.ModeDir | os.ModeSticky | 0o776, "drwxrwxrwT"},
+		{os.ModeSetuid | 0o755, "-rwsr-xr-x"},
+		{os.ModeSetuid | 0o644, "-rwSr--r--"},
+		{os.ModeSetgid | 0o755, "-rwxr-sr-x"},
+		{os.ModeSetgid | 0o745, "-rwxr-Sr-x"},
+		{os.ModeSetuid | os.ModeSetgid | 0o755, "-rwsr-sr-x"},
+		{os.ModeSetuid | os.ModeSetgid | os.ModeSticky | 0o777, "-rwsrwsrwt"},
+		{os.ModeSetuid | os.ModeSetgid | os.ModeSticky, "---S--S--T"},
+		{os.ModeSymlink | 0o777, "lrwxrwxrwx"},
+		{os.ModeNamedPipe | 0o644, "prw-r--r--"},
+		{os.ModeSocket | 0o755, "srwxr-xr-x"},
+		{os.ModeDevice | os.ModeCharDevice | 0o666, "crw-rw-rw-"},
+		{os.ModeDevice | 0o660, "brw-rw----"},
+	}
+	for _, tt := range tests {
+		if got := getPermissions(tt.mode); got != tt.want {
+			t.Errorf("getPermissions(%v) = %q, want %q", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestSpecialPermissionBits(t *testing.T) {
+	// Modes are written as chmod takes them. The strings are GNU ls's.
+	tests := []struct {
+		mode uint32
+		want string
+	}{
+		{0o755, "-rwxr-xr-x"},
+		{0o1777, "-rwxrwxrwt"},
+		{0o4755, "-rwsr-xr-x"},
+		{0o2755, "-rwxr-sr-x"},
+		{0o6755, "-rwsr-sr-x"},
+		{0o7777, "-rwsrwsrwt"},
+	}
+	for _, tt := range tests {
+		mode := os.FileMode(tt.mode & 0o777)
+		if tt.mode&0o4000 != 0 {
+			mode |= os.ModeSetuid
+		}
+		if tt.mode&0o2000 != 0 {
+			mode |= os.ModeSetgid
+		}
+		if tt.mode&0o1000 != 0 {
+			mode |= os.ModeSticky
+		}
+		if got := getPermissions(mode); got != tt.want {
+			t.Errorf("getPermissions(%04o) = %q, want %q", tt.mode, got, tt.want)
+		}
+	}
+}
+
+// concatPermissions is the getPermissions that built the string by
+// replacing one character at a time, kept as a baseline for
+// BenchmarkGetPermissions.
+func concatPermissions(mode os.FileMode) string {
+	setCharAt := func(str string, index int, char byte) string {
+		return str[:index] + string(char) + str[index+1:]
+	}
+	perms := "----------"
+	perms = setCharAt(perms, 0, fileTypeChar(mode))
+	if mode&0o400 != 0 {
+		perms = setCharAt(perms, 1, 'r')
+	}
+	if mode&0o200 != 0 {
+		perms = setCharAt(perms, 2, 'w')
+	}
+	perms = setCharAt(perms, 3, execChar(mode&0o100 != 0, mode&os.ModeSetuid != 0, 's'))
+	if mode&0o040 != 0 {
+		perms = setCharAt(perms, 4, 'r')
+	}
+	if mode&0o020 != 0 {
+		perms = setCharAt(perms, 5, 'w')
+	}
+	perms = setCharAt(perms, 6, execChar(mode&0o010 != 0, mode&os.ModeSetgid != 0, 's'))
+	if mode&0o004 != 0 {
+		perms = setCharAt(perms, 7, 'r')
+	}
+	if mode&0o002 != 0 {
+		perms = setCharAt(perms, 8, 'w')
+	}
+	perms = setCharAt(perms, 9, execChar(mode&0o001 != 0, mode&os.ModeSticky != 0, 't'))
+	return perms
+}
+
+func BenchmarkGetPermissions(b *testing.B) {
+	modes := []os.FileMode{0o644, os.ModeDir | 0o755, os.ModeSetuid | 0o4755, os.ModeSymlink | 0o777}
+	for _, mode := range modes {
+		if got, want := getPermissions(mode), concatPermissions(mode); got != want {
+			b.Fatalf("getPermissions(%v) = %q, baseline gives %q", mode, got, want)
+		}
+	}
+	b.Run("buffer", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			getPermissions(modes[i%len(modes)])
+		}
+	})
+	b.Run("concat", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			concatPermissions(modes[i%len(modes)])
+		}
+	})
+}
+
+func TestLongTotal(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a": strings.Repeat("x", 10000), "b": "x", "d/": ""})
+	out := mustList(t, &Lister{Format: FormatLong}, dir)
+	first := lines(out)[0]
+	if !strings.HasPrefix(first, "total ") {
+		t.Fatalf("first line is %q, want the total", first)
+	}
+
+	var blocks int64
+	for _, name := range []string{"a", "b", "d"} {
+		info, err := os.Lstat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		stat, ok := sysStat(info)
+		if !ok {
+			t.Skip("no block counts on this platform")
+		}
+		blocks += stat.blocks
+	}
+	if want := "total " + strconv.FormatInt((blocks+1)/2, 10); first != want {
+		t.Errorf("got %q, want %q", first, want)
+	}
+	human := lines(mustList(t, &Lister{Format: FormatLong, HumanReadable: true}, dir))[0]
+	if want := "total " + humanSize(blocks*512); human != want {
+		t.Errorf("-h: got %q, want %q", human, want)
+	}
+	scaled := lines(mustList(t, &Lister{Format: FormatLong, BlockSize: BlockSize{Bytes: 512}}, dir))[0]
+	if want := "total " + strconv.FormatInt(blocks, 10); scaled != want {
+		t.Errorf("--block-size=512: got %q, want %q", scaled, want)
+	}
+	if out := mustList(t, &Lister{Format: FormatLong}, filepath.Join(dir, "a")); strings.HasPrefix(out, "total") {
+		t.Errorf("a file argument got a total line:\n%s", out)
+	}
+	if out := mustList(t, &Lister{}, dir); strings.HasPrefix(out, "total") {
+		t.Errorf("a listing without Long got a total line:\n%s", out)
+	}
+}
+
+func TestLongTotalWithAll(t *testing.T) {
+	dir := makeFiles(t, map[string]string{".hidden": strings.Repeat("x", 10000), "a": "x"})
+	total := func(l *Lister) string {
+		return lines(mustList(t, l, dir))[0]
+	}
+	var blocks int64
+	for _, name := range []string{".", "..", ".hidden", "a"} {
+		info, err := os.Lstat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		stat, ok := sysStat(info)
+		if !ok {
+			t.Skip("no block counts on this platform")
+		}
+		blocks += stat.blocks
+	}
+	// The total counts what is listed, so -a adds ".", ".." and .hidden.
+	if got, want := total(&Lister{Format: FormatLong, All: true}), "total "+strconv.FormatInt((blocks+1)/2, 10); got != want {
+		t.Errorf("-la: got %q, want %q", got, want)
+	}
+	if all, plain := total(&Lister{Format: FormatLong, All: true}), total(&Lister{Format: FormatLong}); all == plain {
+		t.Errorf("-la and -l both give %q", all)
+	}
+}
+
+func TestLongSizeAlignment(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a": "x", "b": strings.Repeat("x", 12345)})
+	got := lines(mustList(t, &Lister{Format: FormatLong}, dir))[1:]
+	if len(got) != 2 {
+		t.Fatalf("got %q", got)
+	}
+	// Right-aligned sizes end in the same column, so every row is as
+	// long as the others.
+	if len(got[0]) != len(got[1]) || !strings.Contains(got[0], "     1 ") {
+		t.Errorf("sizes are not right-aligned:\n%s\n%s", got[0], got[1])
+	}
+}
+
+func TestLongSymlink(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"target": ""})
+	symlink(t, "target", filepath.Join(dir, "link"))
+	symlink(t, "missing", filepath.Join(dir, "broken"))
+
+	// Links are listed as themselves, so a broken one is no error.
+	out := mustList(t, &Lister{Format: FormatLong}, dir)
+	for _, want := range []string{" broken -> missing\n", " link -> target\n", " target\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("listing lacks %q:\n%s", want, out)
+		}
+	}
+	for _, row := range longFields(out) {
+		if isLink := row[len(row)-2] == "->"; isLink != strings.HasPrefix(row[0], "l") {
+			t.Errorf("permissions do not match the link: %q", row)
+		}
+	}
+}
+
+func TestShortListingHasNoDetails(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a": "data"})
+	symlink(t, "a", filepath.Join(dir, "link"))
+	if got := mustList(t, &Lister{}, dir); got != "a\nlink\n" {
+		t.Errorf("got %q, want just the names", got)
+	}
+	path := filepath.Join(dir, "a")
+	if got := mustList(t, &Lister{}, path); got != path+"\n" {
+		t.Errorf("file argument: got %q, want just its name", got)
+	}
+}
+
+func TestLongInode(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a": ""})
+	info, err := os.Lstat(filepath.Join(dir, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stat, ok := sysStat(info)
+	if !ok {
+		t.Skip("no inode numbers on this platform")
+	}
+	ino := strconv.FormatUint(stat.ino, 10)
+
+	if got := mustList(t, &Lister{Inode: true}, dir); got != ino+" a\n" {
+		t.Errorf("-i: got %q, want %q", got, ino+" a\n")
+	}
+	rows := longFields(mustList(t, &Lister{Format: FormatLong, Inode: true}, dir))
+	if len(rows) != 1 || rows[0][0] != ino || !strings.HasPrefix(rows[0][1], "-rw") {
+		t.Errorf("-li: got %q, want the inode before the permissions", rows)
+	}
+}
+
+func TestLongFullTime(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a": ""})
+	mtime := time.Date(2020, 3, 4, 5, 6, 7, 890, time.Local)
+	setModTime(t, filepath.Join(dir, "a"), mtime)
+
+	out := mustList(t, &Lister{Format: FormatLong, TimeStyle: TimeStyleFullISO}, dir)
+	if want := mtime.Format("2006-01-02 15:04:05.000000000 -0700") + " a\n"; !strings.HasSuffix(out, want) {
+		t.Errorf("got %q, want it to end with %q", out, want)
+	}
+}
+
+func TestFormatTime(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		style TimeStyle
+		t     time.Time
+		want  string
+	}{
+		{TimeStyleLocale, now.Add(-time.Hour), "Jun 15 11:00"},
+		{TimeStyleLocale, time.Date(2024, 6, 5, 9, 30, 0, 0, time.UTC), "Jun  5 09:30"},
+		{TimeStyleLocale, time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), "Jan  2  2023"},
+		{TimeStyleLocale, now.Add(time.Hour), "Jun 15  2024"},
+		{TimeStyleLongISO, now, "2024-06-15 12:00"},
+		{TimeStyleISO, now.Add(-time.Hour), "06-15 11:00"},
+		{TimeStyleISO, time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), "2023-01-02 "},
+		{TimeStyleFullISO, now, "2024-06-15 12:00:00.000000000 +0000"},
+	}
+	for _, tt := range tests {
+		l := &Lister{TimeStyle: tt.style, now: now}
+		if got := l.formatTime(tt.t); got != tt.want {
+			t.Errorf("style %d, %v: got %q, want %q", tt.style, tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestLongNumericIDs(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a": ""})
+	info, err := os.Lstat(filepath.Join(dir, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stat, ok := sysStat(info)
+	if !ok {
+		t.Skip("no owners on this platform")
+	}
+	row := longFields(mustList(t, &Lister{Format: FormatLong, NumericIDs: true}, dir))[0]
+	if row[2] != strconv.Itoa(stat.uid) || row[3] != strconv.Itoa(stat.gid) {
+		t.Errorf("got %q, want uid %d and gid %d", row, stat.uid, stat.gid)
+	}
+	if row[0] != "-rw-r--r--" || row[1] != "1" || row[4] != "0" || row[len(row)-1] != "a" {
+		t.Errorf("columns out of order: %q", row)
+	}
+}
+
+func TestLongLinkCount(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a": "", "sub/": ""})
+	if err := os.Link(filepath.Join(dir, "a"), filepath.Join(dir, "b")); err != nil {
+		t.Skipf("cannot create hard links: %v", err)
+	}
+	rows := longFields(mustList(t, &Lister{Format: FormatLong}, dir))
+	for _, row := range rows {
+		// An empty directory is linked from its parent and from its own
+		// ".", just as a and b are linked from each other.
+		if row[1] != "2" {
+			t.Errorf("%s: link count %s, want 2", row[len(row)-1], row[1])
+		}
+	}
+}
+
+func TestLongBlockSize(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a": strings.Repeat("x", 1500)})
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"1", "1500"},
+		{"K", "2K"},
+		{"1K", "2"},
+		{"kB", "2kB"},
+		{"512", "3"},
+	}
+	for _, tt := range tests {
+		size, err := ParseBlockSize(tt.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		row := longFields(mustList(t, &Lister{Format: FormatLong, BlockSize: size}, dir))[0]
+		if row[4] != tt.want {
+			t.Errorf("--block-size=%s: size %q, want %q", tt.spec, row[4], tt.want)
+		}
+	}
+}
+
+// fakeInfo is a FileInfo with no Sys, like those of some virtual
+// filesystems.
+type fakeInfo struct {
+	name string
+	size int64
+	mode os.FileMode
+}
+
+func (fi fakeInfo) Name() string       { return fi.name }
+func (fi fakeInfo) Size() int64        { return fi.size }
+func (fi fakeInfo) Mode() os.FileMode  { return fi.mode }
+func (fi fakeInfo) ModTime() time.Time { return time.Time{} }
+func (fi fakeInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi fakeInfo) Sys() any           { return nil }
+
+func TestFileDetailsWithoutSys(t *testing.T) {
+	l, _ := preparedLister(t, &Lister{Format: FormatLong})
+	e := l.newEntry(t.TempDir(), "virtual", 0)
+	e.info, e.statted = fakeInfo{name: "virtual", size: 42, mode: 0o644}, true
+
+	row := l.getFileDetails(e, "virtual")
+	if row.links != "?" || row.owner != "?" || row.group != "?" || row.inode != "?" {
+		t.Errorf("got %+v, want \"?\" for the stat fields", row)
+	}
+	if row.size != "42" || row.permissions != "-rw-r--r--" {
+		t.Errorf("got %+v", row)
+	}
+}
+
+func TestLongUnknownTime(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a": "", "b": ""})
+	saved := lstatFile
+	t.Cleanup(func() { lstatFile = saved })
+	lstatFile = func(path string) (os.FileInfo, error) {
+		if filepath.Dir(path) != dir {
+			return saved(path)
+		}
+		// Vanished entries are reported together, so fail with
+		// another error to get one warning each.
+		return nil, &os.PathError{Op: "lstat", Path: path, Err: fs.ErrPermission}
+	}
+
+	out, errOut, err := list(t, &Lister{Format: FormatLong}, dir)
+	if !errors.Is(err, ErrMinor) || len(lines(errOut)) != 2 {
+		t.Errorf("err = %v, stderr %q, want a minor error for each entry", err, errOut)
+	}
+	// The "?" is padded to the 12 columns of a timestamp such as
+	// "Jan  2 15:04", so names line up with those of other listings.
+	want := "total 0\n" +
+		"-????????? ? ? ? ?            ? a\n" +
+		"-????????? ? ? ? ?            ? b\n"
+	if out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
+
+func TestSizeColor(t *testing.T) {
+	tests := []struct {
+		size int64
+		want string
+	}{
+		{0, "32"},
+		{1023, "32"},
+		{1024, "33"},
+		{1<<20 - 1, "33"},
+		{1 << 20, "31"},
+		{1<<30 - 1, "31"},
+		{1 << 30, "01;31"},
+		{1 << 40, "01;31"},
+	}
+	for _, tt := range tests {
+		if got := sizeColor(tt.size); got != tt.want {
+			t.Errorf("sizeColor(%d) = %q, want %q", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestLongColorScale(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"small": "x"})
+	if err := os.Truncate(filepath.Join(dir, "small"), 0); err != nil {
+		t.Fatal(err)
+	}
+	big := filepath.Join(dir, "big")
+	f, err := os.Create(big)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	// A sparse file has the size without using the disk space.
+	if err := os.Truncate(big, 1<<30); err != nil {
+		t.Skipf("cannot create a sparse file: %v", err)
+	}
+
+	out := mustList(t, &Lister{Format: FormatLong, Color: ColorAlways, ColorScale: true}, dir)
+	if !strings.Contains(out, "\x1b[01;31m1073741824\x1b[0m") {
+		t.Errorf("1G size not in bold red:\n%q", out)
+	}
+	if !strings.Contains(out, " \x1b[32m0\x1b[0m ") {
+		t.Errorf("empty file size not in green:\n%q", out)
+	}
+	if out := mustList(t, &Lister{Format: FormatLong, ColorScale: true}, dir); strings.Contains(out, "\x1b") {
+		t.Errorf("sizes colored without color output:\n%q", out)
+	}
+}
+
+func TestAllocatedSizeOfSparseFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sparse")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if err := os.Truncate(path, 1<<30); err != nil {
+		t.Skipf("cannot create a sparse file: %v", err)
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stat, ok := sysStat(info)
+	if !ok {
+		t.Skip("no block counts on this platform")
+	}
+	want := strconv.FormatInt((stat.blocks+1)/2, 10) + " " + path + "\n"
+	if got := mustList(t, &Lister{AllocatedSize: true}, path); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestAllocatedSizeTotal(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a": strings.Repeat("x", 10000)})
+	// Like GNU ls, -s shows the total line in short listings too.
+	got := lines(mustList(t, &Lister{AllocatedSize: true}, dir))
+	if len(got) != 2 || !strings.HasPrefix(got[0], "total ") || !strings.HasSuffix(got[1], " a") {
+		t.Errorf("got %q, want a total line and a sized name", got)
+	}
+}
+
+func TestLongNoOwnerOrGroup(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a": ""})
+	tests := []struct {
+		lister Lister
+		fields int
+	}{
+		{Lister{Format: FormatLong}, 9},
+		{Lister{Format: FormatLong, NoOwner: true}, 8},
+		{Lister{Format: FormatLong, NoGroup: true}, 8},
+		{Lister{Format: FormatLong, NoOwner: true, NoGroup: true}, 7},
+	}
+	for _, tt := range tests {
+		row := longFields(mustList(t, &tt.lister, dir))[0]
+		if len(row) != tt.fields {
+			t.Errorf("NoOwner=%v NoGroup=%v: %d fields in %q, want %d",
+				tt.lister.NoOwner, tt.lister.NoGroup, len(row), row, tt.fields)
+		}
+	}
+}
+
+// countLookups counts the owner and group lookups made until the test
+// ends.
+func countLookups(tb testing.TB) *int {
+	savedUser, savedGroup := lookupUser, lookupGroup
+	tb.Cleanup(func() { lookupUser, lookupGroup = savedUser, savedGroup })
+	var count int
+	lookupUser = func(uid string) (*user.User, error) {
+		count++
+		return savedUser(uid)
+	}
+	lookupGroup = func(gid string) (*user.Group, error) {
+		count++
+		return savedGroup(gid)
+	}
+	return &count
+}
+
+func TestOwnerCache(t *testing.T) {
+	l, _ := preparedLister(t, &Lister{Format: FormatLong})
+	uid := os.Getuid()
+	if uid < 0 {
+		t.Skip("no user IDs on this platform")
+	}
+	lookups := countLookups(t)
+	first, _ := l.getOwner(uid)
+	if got, _ := l.getOwner(uid); got != first || *lookups != 1 {
+		t.Errorf("getOwner(%d) gave %q then %q with %d lookups", uid, first, got, *lookups)
+	}
+}
+
+func BenchmarkOwnerLookups(b *testing.B) {
+	if os.Getuid() < 0 {
+		b.Skip("no user IDs on this platform")
+	}
+	// Every file has the same owner and group, so a listing needs one
+	// lookup of each however many files there are.
+	dir := b.TempDir()
+	for i := 0; i < 10000; i++ {
+		if err := os.WriteFile(filepath.Join(dir, "f"+strconv.Itoa(i)), nil, 0o644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	lookups := countLookups(b)
+	l := &Lister{Format: FormatLong, Out: io.Discard, Err: io.Discard}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := l.List([]string{dir}); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(*lookups)/float64(b.N), "lookups/op")
+}
+
+func BenchmarkLongListing(b *testing.B) {
+	dir := b.TempDir()
+	for i := 0; i < 1000; i++ {
+		if err := os.WriteFile(filepath.Join(dir, "f"+strconv.Itoa(i)), nil, 0o644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	var out, errOut strings.Builder
+	l := &Lister{Format: FormatLong, Out: &out, Err: &errOut}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		out.Reset()
+		if err := l.List([]string{dir}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
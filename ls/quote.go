@@ -0,0 +1,201 @@
+package ls
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// QuotingStyle selects how names with special characters are written.
+type QuotingStyle int
+
+const (
+	// QuoteDefault uses QuoteShellEscape on a terminal and QuoteLiteral
+	// otherwise, like GNU ls.
+	QuoteDefault QuotingStyle = iota
+	// QuoteLiteral writes names as they are, as with -N.
+	QuoteLiteral
+	// QuoteShell wraps names that need it in single quotes, leaving
+	// control characters as they are.
+	QuoteShell
+	// QuoteShellAlways is QuoteShell for every name.
+	QuoteShellAlways
+	// QuoteShellEscape wraps names that need it in single quotes and
+	// writes control characters as $'\n' sequences.
+	QuoteShellEscape
+	// QuoteShellEscapeAlways is QuoteShellEscape for every name.
+	QuoteShellEscapeAlways
+	// QuoteC wraps every name in double quotes with C escapes, as with -Q.
+	QuoteC
+	// QuoteEscape uses C escapes without the quotes, as with -b.
+	QuoteEscape
+)
+
+// ControlChars selects how control characters left in names by the
+// quoting style are written.
+type ControlChars int
+
+const (
+	// ControlDefault uses ControlHide on a terminal and ControlShow
+	// otherwise, like GNU ls.
+	ControlDefault ControlChars = iota
+	// ControlHide writes each control character as '?', as with -q.
+	ControlHide
+	// ControlShow writes control characters as they are, as with
+	// --show-control-chars.
+	ControlShow
+)
+
+func (l *Lister) quote(name string) string {
+	switch l.quoting {
+	case QuoteShell, QuoteShellAlways:
+		name = shellQuote(name, l.quoting == QuoteShellAlways)
+	case QuoteShellEscape, QuoteShellEscapeAlways:
+		return shellEscape(name, l.quoting == QuoteShellEscapeAlways)
+	case QuoteC:
+		return `"` + cEscape(name, false) + `"`
+	case QuoteEscape:
+		return cEscape(name, true)
+	}
+	if l.hideControl {
+		name = hideControls(name)
+	}
+	return name
+}
+
+// hideControls replaces each control character in name, and each byte
+// that is not valid UTF-8, with '?'.
+func hideControls(name string) string {
+	if strings.IndexFunc(name, isControl) < 0 {
+		return name
+	}
+	var b strings.Builder
+	for i := 0; i < len(name); {
+		r, size := utf8.DecodeRuneInString(name[i:])
+		if isControl(r) {
+			b.WriteByte('?')
+		} else {
+			b.WriteString(name[i : i+size])
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// shellSpecial holds the characters that make GNU ls quote a name for a
+// shell wherever they appear. "~" and "#" only matter at the start of a
+// word.
+const shellSpecial = " \t\n!\"$&'()*;<=>?[\\^`|"
+
+func needsShellQuoting(name string) bool {
+	return hasShellSpecial(name) || strings.IndexFunc(name, isControl) >= 0
+}
+
+func hasShellSpecial(name string) bool {
+	return name == "" || name[0] == '~' || name[0] == '#' || strings.ContainsAny(name, shellSpecial)
+}
+
+// doubleQuotes reports whether name can go in double quotes rather than
+// single quotes: it holds a single quote and nothing a shell expands
+// inside double quotes.
+func doubleQuotes(name string) bool {
+	return strings.Contains(name, "'") && !strings.ContainsAny(name, "\"$`\\!")
+}
+
+// shellQuote wraps name in single quotes if it needs them, or always when
+// always is set. Control characters are left as they are, so the result
+// only pastes back into a shell when there are none.
+func shellQuote(name string, always bool) string {
+	if !always && !hasShellSpecial(name) {
+		return name
+	}
+	if doubleQuotes(name) {
+		return `"` + name + `"`
+	}
+	return "'" + strings.ReplaceAll(name, "'", `'\''`) + "'"
+}
+
+func isControl(r rune) bool {
+	return r == utf8.RuneError || !unicode.IsPrint(r)
+}
+
+// shellEscape quotes name so it can be pasted into a shell. Printable runs
+// go in single quotes and control characters in $'...' runs, so "a\nb"
+// becomes the runs 'a', $'\n' and 'b'. Names whose only awkward character
+// is a single quote are wrapped in double quotes instead, as GNU ls does.
+// With always set, names that need no quoting are quoted too.
+func shellEscape(name string, always bool) string {
+	if !always && !needsShellQuoting(name) {
+		return name
+	}
+	hasControl := strings.IndexFunc(name, isControl) >= 0
+	if !hasControl && doubleQuotes(name) {
+		return `"` + name + `"`
+	}
+
+	// Like GNU ls, a name that starts with a control character still
+	// starts with a quote, so "\n" becomes ''$'\n'.
+	var b strings.Builder
+	if r, _ := utf8.DecodeRuneInString(name); isControl(r) {
+		b.WriteString("''")
+	}
+	for name != "" {
+		i := strings.IndexFunc(name, isControl)
+		if i < 0 {
+			i = len(name)
+		}
+		if i > 0 {
+			b.WriteString("'" + strings.ReplaceAll(name[:i], "'", `'\''`) + "'")
+			name = name[i:]
+			continue
+		}
+
+		j := strings.IndexFunc(name, func(r rune) bool { return !isControl(r) })
+		if j < 0 {
+			j = len(name)
+		}
+		b.WriteString("$'" + cEscape(name[:j], false) + "'")
+		name = name[j:]
+	}
+	return b.String()
+}
+
+// cEscape writes backslashes, double quotes and non-printable characters
+// as C escapes. With spaces set, spaces are escaped too.
+func cEscape(name string, spaces bool) string {
+	var b strings.Builder
+	for i := 0; i < len(name); {
+		r, size := utf8.DecodeRuneInString(name[i:])
+		switch {
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '"' && !spaces:
+			b.WriteString(`\"`)
+		case r == ' ' && spaces:
+			b.WriteString(`\ `)
+		case r == '\a':
+			b.WriteString(`\a`)
+		case r == '\b':
+			b.WriteString(`\b`)
+		case r == '\f':
+			b.WriteString(`\f`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\v':
+			b.WriteString(`\v`)
+		case isControl(r):
+			for _, c := range []byte(name[i : i+size]) {
+				fmt.Fprintf(&b, `\%03o`, c)
+			}
+		default:
+			b.WriteString(name[i : i+size])
+		}
+		i += size
+	}
+	return b.String()
+}
@@ -0,0 +1,180 @@
+package ls
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestQuotingStyles(t *testing.T) {
+	// The quoted forms are what GNU ls writes for each name.
+	tests := []struct {
+		style QuotingStyle
+		name  string
+		want  string
+	}{
+		{QuoteLiteral, "my file", "my file"},
+		{QuoteLiteral, "new\nline", "new\nline"},
+
+		{QuoteShell, "plain", "plain"},
+		{QuoteShell, "my file", "'my file'"},
+		{QuoteShell, `q"uote`, `'q"uote'`},
+		{QuoteShell, "new\nline", "'new\nline'"},
+		{QuoteShell, "it's", `"it's"`},
+		{QuoteShell, "~home", "'~home'"},
+		{QuoteShell, "a~b", "a~b"},
+		{QuoteShell, "a=b", "'a=b'"},
+		{QuoteShell, "=x", "'=x'"},
+		{QuoteShell, "a[b", "'a[b'"},
+		{QuoteShell, "a]b", "a]b"},
+		{QuoteShell, "a{b", "a{b"},
+		{QuoteShell, "a}b", "a}b"},
+		{QuoteShell, "{x", "{x"},
+		{QuoteShell, "}x", "}x"},
+		{QuoteShellAlways, "plain", "'plain'"},
+
+		{QuoteShellEscape, "plain", "plain"},
+		{QuoteShellEscape, "my file", "'my file'"},
+		{QuoteShellEscape, "~home", "'~home'"},
+		{QuoteShellEscape, "a~b", "a~b"},
+		{QuoteShellEscape, "a=b", "'a=b'"},
+		{QuoteShellEscape, "=x", "'=x'"},
+		{QuoteShellEscape, "a[b", "'a[b'"},
+		{QuoteShellEscape, "a]b", "a]b"},
+		{QuoteShellEscape, "a{b", "a{b"},
+		{QuoteShellEscape, "a}b", "a}b"},
+		{QuoteShellEscape, "{x}", "{x}"},
+		{QuoteShellEscape, "new\nline", `'new'$'\n''line'`},
+		{QuoteShellEscape, "tab\there", `'tab'$'\t''here'`},
+		{QuoteShellEscape, "x\x01y", `'x'$'\001''y'`},
+		{QuoteShellEscape, "\n", `''$'\n'`},
+		{QuoteShellEscape, "\x01\x02", `''$'\001\002'`},
+		{QuoteShellEscape, "ab\n", `'ab'$'\n'`},
+		{QuoteShellEscape, "it's", `"it's"`},
+		{QuoteShellEscape, "it's $x", `'it'\''s $x'`},
+		{QuoteShellEscapeAlways, "plain", "'plain'"},
+
+		{QuoteC, "plain", `"plain"`},
+		{QuoteC, "my file", `"my file"`},
+		{QuoteC, `q"uote`, `"q\"uote"`},
+		{QuoteC, "new\nline", `"new\nline"`},
+		{QuoteC, "x\x01y", `"x\001y"`},
+		{QuoteC, `back\slash`, `"back\\slash"`},
+
+		{QuoteEscape, "my file", `my\ file`},
+		{QuoteEscape, `q"uote`, `q"uote`},
+		{QuoteEscape, "new\nline", `new\nline`},
+		{QuoteEscape, "tab\there", `tab\there`},
+		{QuoteEscape, "x\x01y", `x\001y`},
+		{QuoteEscape, "caf\xe9", `caf\351`},
+	}
+	for _, tt := range tests {
+		l := &Lister{quoting: tt.style}
+		if got := l.quote(tt.name); got != tt.want {
+			t.Errorf("style %d: quote(%q) = %q, want %q", tt.style, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHideControls(t *testing.T) {
+	tests := []struct{ name, want string }{
+		{"plain", "plain"},
+		{"x\x01y", "x?y"},
+		{"new\nline", "new?line"},
+		{"caf\xe9", "caf?"},
+		{"café", "café"},
+		{"\x7f", "?"},
+	}
+	for _, tt := range tests {
+		if got := hideControls(tt.name); got != tt.want {
+			t.Errorf("hideControls(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestQuoteHidesControlsAfterShellQuoting(t *testing.T) {
+	l := &Lister{quoting: QuoteShell, hideControl: true}
+	if got, want := l.quote("new\nline"), "'new?line'"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	// Escaping styles leave nothing to hide.
+	l.quoting = QuoteEscape
+	if got, want := l.quote("x\x01y"), `x\001y`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestListQuoting(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a b": "", "plain": "", "x\x01y": ""})
+	tests := []struct {
+		lister Lister
+		want   string
+	}{
+		{Lister{}, "a b\nplain\nx\x01y\n"},
+		{Lister{Quoting: QuoteC}, "\"a b\"\n\"plain\"\n\"x\\001y\"\n"},
+		{Lister{Quoting: QuoteShellEscape}, "'a b'\nplain\n'x'$'\\001''y'\n"},
+		{Lister{ControlChars: ControlHide}, "a b\nplain\nx?y\n"},
+	}
+	for _, tt := range tests {
+		if got := mustList(t, &tt.lister, dir); got != tt.want {
+			t.Errorf("Quoting=%d ControlChars=%d: got %q, want %q",
+				tt.lister.Quoting, tt.lister.ControlChars, got, tt.want)
+		}
+	}
+}
+
+func TestQuoteNamesPadsForColumns(t *testing.T) {
+	// Like GNU ls, when some names are quoted the others get a space in
+	// front, so the names still line up.
+	l := &Lister{quoting: QuoteShellEscape, format: FormatColumns, width: 80}
+	got := l.quoteNames(entriesNamed("a b", "cd"))
+	if want := []string{"'a b'", " cd"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	l.quoting = QuoteShell
+	got = l.quoteNames(entriesNamed("a b", "cd"))
+	if want := []string{"'a b'", " cd"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("shell: got %q, want %q", got, want)
+	}
+	// A name changed only by '?' replacement is not quoted, so it is
+	// padded too.
+	l.hideControl = true
+	got = l.quoteNames(entriesNamed("a b", "x\x01y"))
+	if want := []string{"'a b'", " x?y"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("shell, hidden controls: got %q, want %q", got, want)
+	}
+	l.hideControl = false
+	l.quoting = QuoteShellEscape
+	// Columns without a width limit, one name per line and commas need
+	// no padding.
+	l.width = math.MaxInt
+	got = l.quoteNames(entriesNamed("a b", "cd"))
+	if want := []string{"'a b'", "cd"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("-w0: got %q, want %q", got, want)
+	}
+	l.width, l.format = 80, FormatSingleColumn
+	got = l.quoteNames(entriesNamed("a b", "cd"))
+	if want := []string{"'a b'", "cd"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("-1: got %q, want %q", got, want)
+	}
+	l.format = FormatCommas
+	got = l.quoteNames(entriesNamed("a b", "cd"))
+	if want := []string{"'a b'", "cd"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("-m: got %q, want %q", got, want)
+	}
+}
+
+func TestShellQuotedColumnsAlign(t *testing.T) {
+	// Like GNU ls, when some names are quoted the others get a space in
+	// front, so the names still line up.
+	dir := makeNamed(t, "a b", "cd", "ef")
+	want := "'a b'   ef\n cd\n"
+	if got := mustList(t, &Lister{Format: FormatColumns, Quoting: QuoteShellEscape, Width: 12}, dir); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	// One name per line needs no padding.
+	want = "'a b'\ncd\nef\n"
+	if got := mustList(t, &Lister{Format: FormatSingleColumn, Quoting: QuoteShellEscape}, dir); got != want {
+		t.Errorf("-1: got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,327 @@
+package ls
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SortKey selects the order entries are listed in.
+type SortKey int
+
+const (
+	// SortDefault sorts by name, or like GNU ls by the time chosen with
+	// Time when it is not the modification time and the format is not
+	// FormatLong.
+	SortDefault SortKey = iota
+	// SortName sorts by name.
+	SortName
+	// SortNone keeps the order the directory was read in, as with -U.
+	SortNone
+	// SortTime sorts newest first, as with -t.
+	SortTime
+	// SortSize sorts largest first, as with -S.
+	SortSize
+	// SortExtension sorts by extension, as with -X.
+	SortExtension
+	// SortVersion sorts numbers within names by value, as with -v.
+	SortVersion
+)
+
+func (l *Lister) sortKey() SortKey {
+	if l.Sort != SortDefault {
+		return l.Sort
+	}
+	// Like GNU ls, -c and -u sort by time on their own unless -l is
+	// given, in which case they only change the timestamp shown.
+	if l.Time != TimeModify && l.format != FormatLong {
+		return SortTime
+	}
+	return SortName
+}
+
+// sortEntries orders entries using the selected sort key.
+func (l *Lister) sortEntries(entries []*entry) {
+	switch l.sortKey() {
+	case SortNone:
+		return
+	case SortSize:
+		sortSliceBySize(entries)
+	case SortTime:
+		l.sortSliceByTime(entries)
+	case SortExtension:
+		sortSliceByExtension(entries)
+	case SortVersion:
+		sortSliceByVersion(entries)
+	default:
+		sortSliceByName(entries)
+	}
+
+	// Every sort is a total order, so reversing the result is the same
+	// as reversing the comparison.
+	if l.Reverse {
+		reverseEntries(entries)
+	}
+
+	switch {
+	case l.GroupDirectoriesFirst:
+		groupDirectories(entries, true)
+	case l.GroupDirectoriesLast:
+		groupDirectories(entries, false)
+	}
+}
+
+// groupDirectories moves directories, and symlinks to them, ahead of or
+// after other entries while keeping the sorted order within each group.
+func groupDirectories(entries []*entry, first bool) {
+	var dirs, others []*entry
+	for _, e := range entries {
+		if e.isLinkedDir() {
+			dirs = append(dirs, e)
+		} else {
+			others = append(others, e)
+		}
+	}
+	if first {
+		copy(entries, append(dirs, others...))
+	} else {
+		copy(entries, append(others, dirs...))
+	}
+}
+
+// TimeField selects the timestamp that long listings show and -t sorts by.
+type TimeField int
+
+const (
+	// TimeModify is the last modification time.
+	TimeModify TimeField = iota
+	// TimeChange is the last status change time, as with -c.
+	TimeChange
+	// TimeAccess is the last access time, as with -u.
+	TimeAccess
+)
+
+// statTime returns the chosen timestamp of fileInfo, falling back to the
+// modification time where the platform has no other.
+func statTime(fileInfo os.FileInfo, field TimeField) time.Time {
+	if stat, ok := sysStat(fileInfo); ok {
+		switch field {
+		case TimeChange:
+			return stat.ctime
+		case TimeAccess:
+			return stat.atime
+		}
+	}
+	return fileInfo.ModTime()
+}
+
+// entryTime returns the timestamp e sorts by. Entries that cannot be
+// stat'd get the zero time, which puts them last like GNU ls.
+func (l *Lister) entryTime(e *entry) time.Time {
+	info, err := e.stat()
+	if err != nil {
+		return time.Time{}
+	}
+	return statTime(info, l.Time)
+}
+
+// entrySize returns the size e sorts by, zero if it cannot be stat'd.
+func entrySize(e *entry) int64 {
+	info, err := e.stat()
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+func (l *Lister) sortSliceByTime(slice []*entry) {
+	sort.SliceStable(slice, func(i, j int) bool {
+		timeI, timeJ := l.entryTime(slice[i]), l.entryTime(slice[j])
+		if timeI.Equal(timeJ) {
+			return slice[i].name < slice[j].name
+		}
+		// Newest first, like GNU ls.
+		return timeI.After(timeJ)
+	})
+}
+
+func sortSliceBySize(slice []*entry) {
+	sort.SliceStable(slice, func(i, j int) bool {
+		sizeI, sizeJ := entrySize(slice[i]), entrySize(slice[j])
+		if sizeI == sizeJ {
+			return slice[i].name < slice[j].name
+		}
+		return sizeI > sizeJ
+	})
+}
+
+func sortSliceByExtension(slice []*entry) {
+	sort.SliceStable(slice, func(i, j int) bool {
+		extI, extJ := fileExtension(slice[i].name), fileExtension(slice[j].name)
+		if extI != extJ {
+			return extI < extJ
+		}
+		return slice[i].name < slice[j].name
+	})
+}
+
+// fileExtension returns the text after the last dot in name. A leading dot
+// marks a hidden file rather than an extension, so ".bashrc" has none.
+func fileExtension(name string) string {
+	i := strings.LastIndex(name, ".")
+	if i <= 0 {
+		return ""
+	}
+	return name[i+1:]
+}
+
+func sortSliceByVersion(slice []*entry) {
+	sort.SliceStable(slice, func(i, j int) bool {
+		return compareVersions(slice[i].name, slice[j].name) < 0
+	})
+}
+
+// compareVersions orders names like GNU ls -v, using gnulib's filevercmp
+// and falling back to byte order for names it treats as equal.
+func compareVersions(a, b string) int {
+	if c := filevercmp(a, b); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
+// filevercmp is a port of the gnulib function. "." and ".." sort first,
+// then other hidden names. Suffixes such as ".tar.gz" only break ties
+// between names that are otherwise equal.
+func filevercmp(a, b string) int {
+	switch {
+	case a == "" && b == "":
+		return 0
+	case a == "":
+		return -1
+	case b == "":
+		return 1
+	}
+
+	if a[0] == '.' {
+		if b[0] != '.' {
+			return -1
+		}
+		for _, dots := range []string{".", ".."} {
+			if a == dots {
+				if b == dots {
+					return 0
+				}
+				return -1
+			}
+			if b == dots {
+				return 1
+			}
+		}
+	} else if b[0] == '.' {
+		return 1
+	}
+
+	aPrefix, bPrefix := versionPrefix(a), versionPrefix(b)
+	c := verrevcmp(a[:aPrefix], b[:bPrefix])
+	if c != 0 || aPrefix == len(a) && bPrefix == len(b) {
+		return c
+	}
+	return verrevcmp(a, b)
+}
+
+// versionPrefix returns the length of name without its suffix, the
+// trailing run of parts like ".gz" made of a dot, a letter or "~", and
+// then letters, digits and "~".
+func versionPrefix(name string) int {
+	prefix := 0
+	for i := 0; i < len(name); {
+		i++
+		prefix = i
+		for i+1 < len(name) && name[i] == '.' && (isAlpha(name[i+1]) || name[i+1] == '~') {
+			for i += 2; i < len(name) && (isAlpha(name[i]) || isDigit(name[i]) || name[i] == '~'); i++ {
+			}
+		}
+	}
+	return prefix
+}
+
+// verrevcmp compares a and b as alternating runs of non-digits and
+// digits. Non-digits compare by versionOrder and digits by value.
+func verrevcmp(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		for i < len(a) && !isDigit(a[i]) || j < len(b) && !isDigit(b[j]) {
+			if c := versionOrder(a, i) - versionOrder(b, j); c != 0 {
+				return c
+			}
+			i, j = i+1, j+1
+		}
+		numA, _ := splitDigits(a[i:])
+		numB, _ := splitDigits(b[j:])
+		if c := compareNumbers(numA, numB); c != 0 {
+			return c
+		}
+		i, j = i+len(numA), j+len(numB)
+	}
+	return 0
+}
+
+// versionOrder ranks the character at s[i] for verrevcmp: "~" before the
+// end of the string or a digit, then letters, then everything else.
+func versionOrder(s string, i int) int {
+	switch c := s[i:]; {
+	case c == "" || isDigit(c[0]):
+		return 0
+	case isAlpha(c[0]):
+		return int(c[0])
+	case c[0] == '~':
+		return -1
+	default:
+		return int(c[0]) + 256
+	}
+}
+
+func isAlpha(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// splitDigits splits s after its leading run of digits.
+func splitDigits(s string) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// compareNumbers compares two runs of decimal digits by value. It works on
+// the digits directly so runs too long for an int64 still compare
+// correctly.
+func compareNumbers(a, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	return strings.Compare(a, b)
+}
+
+// sortSliceByName orders names by comparing their bytes, which matches
+// GNU ls in the C locale. Locale-aware collation is not supported.
+func sortSliceByName(slice []*entry) {
+	sort.SliceStable(slice, func(i, j int) bool {
+		return slice[i].name < slice[j].name
+	})
+}
+
+func reverseEntries(slice []*entry) {
+	for i, j := 0, len(slice)-1; i < j; i, j = i+1, j-1 {
+		slice[i], slice[j] = slice[j], slice[i]
+	}
+}
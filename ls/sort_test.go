@@ -0,0 +1,228 @@
+package ls
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// entriesNamed returns entries for names that are never stat'd.
+func entriesNamed(names ...string) []*entry {
+	entries := make([]*entry, len(names))
+	for i, name := range names {
+		entries[i] = &entry{name: name, path: name}
+	}
+	return entries
+}
+
+func entryNames(entries []*entry) []string {
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.name
+	}
+	return names
+}
+
+func TestCompareVersions(t *testing.T) {
+	// The order GNU ls -1v gives these names.
+	want := []string{
+		"~x", "0", "00", "007", "A", "Z.1", "a~", "a", "a1", "a2", "a10", "a b", "a-1", "a.1", "a_1",
+		"b..c", "b.c.1", "f1", "f2", "f10", "f10a", "f10b", "foo.tar", "foo.tar.gz",
+		"foo-1.2.tar.gz", "foo-1.10.tar.gz", "sparse", "sp ace", "x.~1~", "x.~2~",
+	}
+	for i := range want {
+		for j := range want {
+			got := compareVersions(want[i], want[j])
+			if i < j && got >= 0 || i > j && got <= 0 || i == j && got != 0 {
+				t.Errorf("compareVersions(%q, %q) = %d", want[i], want[j], got)
+			}
+		}
+	}
+
+	shuffled := append([]string(nil), want...)
+	rand.New(rand.NewSource(1)).Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	entries := entriesNamed(shuffled...)
+	sortSliceByVersion(entries)
+	if got := entryNames(entries); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("sorted to %q, want %q", got, want)
+	}
+}
+
+func TestCompareVersionsNumbered(t *testing.T) {
+	for _, names := range [][]string{
+		{"f1", "f2", "f10", "f10a", "f10b"},
+		{"file9", "file10", "file100"},
+		{"v1.2", "v1.9", "v1.10", "v2.0"},
+		{".", "..", ".a", "a"},
+	} {
+		for i := 1; i < len(names); i++ {
+			if c := compareVersions(names[i-1], names[i]); c >= 0 {
+				t.Errorf("compareVersions(%q, %q) = %d, want < 0", names[i-1], names[i], c)
+			}
+		}
+	}
+}
+
+func TestFileExtension(t *testing.T) {
+	tests := []struct{ name, want string }{
+		{"a.txt", "txt"},
+		{"a.tar.gz", "gz"},
+		{"Makefile", ""},
+		{".bashrc", ""},
+		{".config.yml", "yml"},
+		{"trailing.", ""},
+	}
+	for _, tt := range tests {
+		if got := fileExtension(tt.name); got != tt.want {
+			t.Errorf("fileExtension(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSortByExtension(t *testing.T) {
+	entries := entriesNamed("b.c", "a.txt", ".hidden", "Makefile", "z.c", "a.c")
+	sortSliceByExtension(entries)
+	want := ".hidden Makefile a.c b.c z.c a.txt"
+	if got := strings.Join(entryNames(entries), " "); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSortBySize(t *testing.T) {
+	dir := makeFiles(t, map[string]string{
+		"small": "x", "large": strings.Repeat("x", 300), "medium": strings.Repeat("x", 20),
+		"tie-b": strings.Repeat("x", 20), "empty": "",
+	})
+	// Largest first, with equal sizes in name order, and -r reverses both.
+	want := "large\nmedium\ntie-b\nsmall\nempty\n"
+	if got := mustList(t, &Lister{Sort: SortSize}, dir); got != want {
+		t.Errorf("-S: got %q, want %q", got, want)
+	}
+	want = "empty\nsmall\ntie-b\nmedium\nlarge\n"
+	if got := mustList(t, &Lister{Sort: SortSize, Reverse: true}, dir); got != want {
+		t.Errorf("-Sr: got %q, want %q", got, want)
+	}
+}
+
+func TestSortBySizeOfSymlink(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"large": strings.Repeat("x", 300), "small": "x"})
+	// The link itself holds the five bytes of "large", as with GNU ls -S.
+	symlink(t, "large", filepath.Join(dir, "link"))
+	if got := mustList(t, &Lister{Sort: SortSize}, dir); got != "large\nlink\nsmall\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestSortByTime(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"old": "", "new": "", "tie-b": "", "tie-a": ""})
+	now := time.Now().Truncate(time.Second)
+	setModTime(t, filepath.Join(dir, "old"), now.Add(-time.Hour))
+	setModTime(t, filepath.Join(dir, "new"), now)
+	setModTime(t, filepath.Join(dir, "tie-a"), now.Add(-time.Minute))
+	setModTime(t, filepath.Join(dir, "tie-b"), now.Add(-time.Minute))
+
+	// Equal times fall back to the name, and -r reverses the whole order.
+	if got := mustList(t, &Lister{Sort: SortTime}, dir); got != "new\ntie-a\ntie-b\nold\n" {
+		t.Errorf("-t: got %q", got)
+	}
+	if got := mustList(t, &Lister{Sort: SortTime, Reverse: true}, dir); got != "old\ntie-b\ntie-a\nnew\n" {
+		t.Errorf("-tr: got %q", got)
+	}
+}
+
+func TestSortByTimeTies(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"tie-c": "", "tie-a": "", "tie-b": ""})
+	now := time.Now().Truncate(time.Second)
+	for _, name := range []string{"tie-c", "tie-a", "tie-b"} {
+		setModTime(t, filepath.Join(dir, name), now)
+	}
+	if got := mustList(t, &Lister{Sort: SortTime}, dir); got != "tie-a\ntie-b\ntie-c\n" {
+		t.Errorf("-t: got %q", got)
+	}
+}
+
+func TestSortByAccessTime(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a": "", "b": ""})
+	now := time.Now()
+	// a was modified last but b was read last.
+	if err := os.Chtimes(filepath.Join(dir, "a"), now.Add(-time.Hour), now); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(filepath.Join(dir, "b"), now, now.Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := mustList(t, &Lister{Time: TimeAccess}, dir); got != "b\na\n" {
+		t.Errorf("-u: got %q, want b first", got)
+	}
+	// With -l, -u only changes the time shown.
+	if got := longFields(mustList(t, &Lister{Format: FormatLong, Time: TimeAccess}, dir)); got[0][len(got[0])-1] != "a" {
+		t.Errorf("-lu: got %q, want name order", got)
+	}
+	if got := mustList(t, &Lister{Time: TimeAccess, Sort: SortTime}, dir); got != "b\na\n" {
+		t.Errorf("-tu: got %q, want b first", got)
+	}
+}
+
+func TestReverse(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"b": "", "c": "", "a": ""})
+	if got := mustList(t, &Lister{Reverse: true}, dir); got != "c\nb\na\n" {
+		t.Errorf("got %q", got)
+	}
+	// Like GNU ls, -r has nothing to reverse without a sort.
+	want := strings.Join(readDirOrder(t, dir), "\n") + "\n"
+	if got := mustList(t, &Lister{Reverse: true, Sort: SortNone}, dir); got != want {
+		t.Errorf("-Ur: got %q, want directory order %q", got, want)
+	}
+}
+
+// selectionSort is the sort the Lister used before sortSliceByName, kept
+// to compare against.
+func selectionSort(slice []*entry) {
+	for i := range slice {
+		min := i
+		for j := i + 1; j < len(slice); j++ {
+			if slice[j].name < slice[min].name {
+				min = j
+			}
+		}
+		slice[i], slice[min] = slice[min], slice[i]
+	}
+}
+
+func benchmarkNames(n int) []*entry {
+	r := rand.New(rand.NewSource(1))
+	entries := make([]*entry, n)
+	for i := range entries {
+		entries[i] = &entry{name: "file" + strconv.Itoa(r.Int())}
+	}
+	return entries
+}
+
+func BenchmarkSortByName(b *testing.B) {
+	for _, n := range []int{1000, 50000} {
+		names := benchmarkNames(n)
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			entries := make([]*entry, n)
+			for i := 0; i < b.N; i++ {
+				copy(entries, names)
+				sortSliceByName(entries)
+			}
+		})
+	}
+}
+
+func BenchmarkSelectionSort(b *testing.B) {
+	names := benchmarkNames(1000)
+	entries := make([]*entry, len(names))
+	for i := 0; i < b.N; i++ {
+		copy(entries, names)
+		selectionSort(entries)
+	}
+}
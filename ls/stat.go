@@ -0,0 +1,16 @@
+package ls
+
+import "time"
+
+// fileStat holds the parts of a stat result that os.FileInfo does not
+// expose portably. sysStat fills it in from FileInfo.Sys on the platforms
+// that have one, and reports false elsewhere.
+type fileStat struct {
+	dev, ino     uint64
+	nlink        uint64
+	uid, gid     int
+	blocks       int64 // in 512-byte units
+	atime, ctime time.Time
+	// major and minor identify the device a device file stands for.
+	major, minor uint32
+}
@@ -0,0 +1,34 @@
+package ls
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+func sysStat(fileInfo os.FileInfo) (fileStat, bool) {
+	sys, ok := fileInfo.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileStat{}, false
+	}
+	return fileStat{
+		dev:    uint64(sys.Dev),
+		ino:    sys.Ino,
+		nlink:  uint64(sys.Nlink),
+		uid:    int(sys.Uid),
+		gid:    int(sys.Gid),
+		blocks: sys.Blocks,
+		atime:  time.Unix(sys.Atimespec.Unix()),
+		ctime:  time.Unix(sys.Ctimespec.Unix()),
+		major:  uint32(sys.Rdev>>24) & 0xff,
+		minor:  uint32(sys.Rdev) & 0xffffff,
+	}, true
+}
+
+func (c *fsCalls) securityContext(path string) string {
+	return "?"
+}
+
+func (c *fsCalls) hasACL(path string) bool {
+	return false
+}
@@ -0,0 +1,84 @@
+package ls
+
+import (
+	"bytes"
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+func sysStat(fileInfo os.FileInfo) (fileStat, bool) {
+	sys, ok := fileInfo.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileStat{}, false
+	}
+	// Device numbers use the glibc encoding, which spreads the major and
+	// minor numbers over both halves of the 64-bit value.
+	rdev := uint64(sys.Rdev)
+	return fileStat{
+		dev:    uint64(sys.Dev),
+		ino:    sys.Ino,
+		nlink:  uint64(sys.Nlink),
+		uid:    int(sys.Uid),
+		gid:    int(sys.Gid),
+		blocks: sys.Blocks,
+		atime:  time.Unix(sys.Atim.Unix()),
+		ctime:  time.Unix(sys.Ctim.Unix()),
+		major:  uint32((rdev>>8)&0xfff | (rdev>>32)&^0xfff),
+		minor:  uint32(rdev&0xff | (rdev>>12)&^0xff),
+	}, true
+}
+
+// lgetxattr reads the extended attribute name of path into buf without
+// following a final symlink. With an empty buf it only reports the size.
+func lgetxattr(path, name string, buf []byte) (int, syscall.Errno) {
+	p, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return 0, syscall.EINVAL
+	}
+	attr, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return 0, syscall.EINVAL
+	}
+
+	var data unsafe.Pointer
+	if len(buf) > 0 {
+		data = unsafe.Pointer(&buf[0])
+	}
+	n, _, errno := syscall.Syscall6(syscall.SYS_LGETXATTR,
+		uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(attr)),
+		uintptr(data), uintptr(len(buf)), 0, 0)
+	return int(n), errno
+}
+
+// securityContext reads the SELinux context of path without following a
+// final symlink. It returns "?" when the file has none.
+func (c *fsCalls) securityContext(path string) string {
+	buf := make([]byte, 256)
+	for {
+		c.xattrs.Add(1)
+		n, errno := lgetxattr(path, "security.selinux", buf)
+		if errno == syscall.ERANGE {
+			buf = make([]byte, 2*len(buf))
+			continue
+		}
+		if errno != 0 || n == 0 {
+			return "?"
+		}
+		return string(bytes.TrimRight(buf[:n], "\x00"))
+	}
+}
+
+// hasACL reports whether path carries a POSIX access or default ACL. An
+// ACL that only repeats the mode bits is stored as no attribute at all, so
+// its presence is enough.
+func (c *fsCalls) hasACL(path string) bool {
+	for _, name := range []string{"system.posix_acl_access", "system.posix_acl_default"} {
+		c.xattrs.Add(1)
+		if n, errno := lgetxattr(path, name, nil); errno == 0 && n > 0 {
+			return true
+		}
+	}
+	return false
+}
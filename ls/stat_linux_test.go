@@ -0,0 +1,100 @@
+package ls
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSysStat(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a": "data"})
+	info, err := os.Lstat(filepath.Join(dir, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stat, ok := sysStat(info)
+	if !ok {
+		t.Fatal("sysStat reported no stat result")
+	}
+	sys := info.Sys().(*syscall.Stat_t)
+	want := fileStat{
+		dev:    uint64(sys.Dev),
+		ino:    sys.Ino,
+		nlink:  uint64(sys.Nlink),
+		uid:    int(sys.Uid),
+		gid:    int(sys.Gid),
+		blocks: sys.Blocks,
+		atime:  time.Unix(sys.Atim.Sec, sys.Atim.Nsec),
+		ctime:  time.Unix(sys.Ctim.Sec, sys.Ctim.Nsec),
+	}
+	if stat != want {
+		t.Errorf("got %+v, want %+v", stat, want)
+	}
+}
+
+func TestSecurityContext(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a": ""})
+	var calls fsCalls
+	context := calls.securityContext(filepath.Join(dir, "a"))
+	got := strings.Fields(mustList(t, &Lister{SecurityContext: true}, dir))
+	if len(got) != 2 || got[0] != context || got[1] != "a" {
+		t.Errorf("got %q, want context %q and the name", got, context)
+	}
+	// Long listings put it after the group.
+	if row := longFields(mustList(t, &Lister{Format: FormatLong, SecurityContext: true}, dir))[0]; row[4] != context {
+		t.Errorf("long: got %q, want context %q after the group", row, context)
+	}
+	if context == "?" {
+		t.Skip("no SELinux labels on this system")
+	}
+	if strings.Count(context, ":") < 2 {
+		t.Errorf("context %q is not user:role:type", context)
+	}
+}
+
+func TestACL(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"plain": "", "shared": ""})
+	if _, err := exec.LookPath("setfacl"); err != nil {
+		t.Skip("setfacl is not installed")
+	}
+	if err := exec.Command("setfacl", "-m", "u:0:r", filepath.Join(dir, "shared")).Run(); err != nil {
+		t.Skipf("cannot set an ACL: %v", err)
+	}
+	rows := longFields(mustList(t, &Lister{Format: FormatLong}, dir))
+	if rows[0][0] != "-rw-r--r--" || !strings.HasSuffix(rows[1][0], "+") {
+		t.Errorf("got %q and %q, want only shared marked", rows[0][0], rows[1][0])
+	}
+}
+
+func TestFIFO(t *testing.T) {
+	dir := t.TempDir()
+	if err := syscall.Mkfifo(filepath.Join(dir, "pipe"), 0o644); err != nil {
+		t.Skipf("cannot create a FIFO: %v", err)
+	}
+	if got := mustList(t, &Lister{Indicator: IndicatorClassify}, dir); got != "pipe|\n" {
+		t.Errorf("-F: got %q", got)
+	}
+	row := longFields(mustList(t, &Lister{Format: FormatLong}, dir))[0]
+	if row[0] != "prw-r--r--" {
+		t.Errorf("-l: permissions %q", row[0])
+	}
+	out := mustList(t, &Lister{Color: ColorAlways}, dir)
+	if out != "\x1b[40;33mpipe\x1b[0m\n" {
+		t.Errorf("color: got %q", out)
+	}
+}
+
+func TestDeviceNumbers(t *testing.T) {
+	info, err := os.Stat("/dev/null")
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		t.Skip("no /dev/null")
+	}
+	row := longFields(mustList(t, &Lister{Format: FormatLong}, "/dev/null"))[0]
+	if row[0][0] != 'c' || row[4] != "1," || row[5] != "3" {
+		t.Errorf("got %q, want a character device 1, 3", row)
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !linux && !darwin
+
+package ls
+
+import "os"
+
+func sysStat(fileInfo os.FileInfo) (fileStat, bool) {
+	return fileStat{}, false
+}
+
+func (c *fsCalls) securityContext(path string) string {
+	return "?"
+}
+
+func (c *fsCalls) hasACL(path string) bool {
+	return false
+}
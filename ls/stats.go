@@ -0,0 +1,60 @@
+package ls
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// fsCalls makes the filesystem calls of a listing and counts them for
+// Lister.Stats. Entries are stat'd concurrently, so the counters are
+// atomic.
+type fsCalls struct {
+	opens, readDirs, stats, readlinks, xattrs atomic.Int64
+	start                                     time.Time
+}
+
+// statFile and lstatFile make the stat calls of every listing. Tests
+// replace them to stand in for a slow filesystem.
+var (
+	statFile  = os.Stat
+	lstatFile = os.Lstat
+)
+
+func (c *fsCalls) open(path string) (*os.File, error) {
+	c.opens.Add(1)
+	return os.Open(path)
+}
+
+func (c *fsCalls) readDir(dir *os.File, n int) ([]os.DirEntry, error) {
+	c.readDirs.Add(1)
+	return dir.ReadDir(n)
+}
+
+func (c *fsCalls) stat(path string) (os.FileInfo, error) {
+	c.stats.Add(1)
+	return statFile(path)
+}
+
+func (c *fsCalls) lstat(path string) (os.FileInfo, error) {
+	c.stats.Add(1)
+	return lstatFile(path)
+}
+
+func (c *fsCalls) fstat(f *os.File) (os.FileInfo, error) {
+	c.stats.Add(1)
+	return f.Stat()
+}
+
+func (c *fsCalls) readlink(path string) (string, error) {
+	c.readlinks.Add(1)
+	return os.Readlink(path)
+}
+
+// String summarizes the counts and the time since the listing started.
+func (c *fsCalls) String() string {
+	return fmt.Sprintf("%d open, %d readdir, %d stat, %d readlink and %d getxattr calls in %v",
+		c.opens.Load(), c.readDirs.Load(), c.stats.Load(), c.readlinks.Load(), c.xattrs.Load(),
+		time.Since(c.start).Round(time.Microsecond))
+}
@@ -0,0 +1,105 @@
+package ls
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+var statsLine = regexp.MustCompile(`^my-ls-1: (\d+) open, (\d+) readdir, (\d+) stat, (\d+) readlink and (\d+) getxattr calls in \S+\n$`)
+
+// listStats lists paths with Stats set and returns the counts it reports
+// as open, readdir, stat, readlink and getxattr.
+func listStats(t testing.TB, l *Lister, paths ...string) [5]int {
+	var out, errOut bytes.Buffer
+	l.Stats, l.Out, l.Err = true, &out, &errOut
+	if err := l.List(paths); err != nil {
+		t.Fatal(err)
+	}
+	m := statsLine.FindStringSubmatch(errOut.String())
+	if m == nil {
+		t.Fatalf("stderr %q holds no stats line", errOut.String())
+	}
+	var counts [5]int
+	for i := range counts {
+		counts[i], _ = strconv.Atoi(m[i+1])
+	}
+	return counts
+}
+
+func TestStatsCounts(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 100; i++ {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%03d", i)), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	symlink(t, "f000", filepath.Join(dir, "link"))
+
+	// A plain listing learns each entry's type from the directory read,
+	// so only the argument is stat'd.
+	if got := listStats(t, &Lister{}, dir); got[0] != 1 || got[2] != 1 || got[3] != 0 {
+		t.Errorf("plain listing: counts %v, want 1 open and 1 stat", got)
+	}
+	if got := listStats(t, &Lister{Sort: SortNone}, dir); got[2] != 1 {
+		t.Errorf("unsorted listing: counts %v, want 1 stat", got)
+	}
+	if got := listStats(t, &Lister{All: true, Sort: SortNone}, dir); got[2] != 1 {
+		t.Errorf("-f listing: counts %v, want 1 stat", got)
+	}
+	// A long listing stats every entry once and reads the link.
+	got := listStats(t, &Lister{Format: FormatLong}, dir)
+	if got[2] < 102 || got[2] > 103 || got[3] != 1 {
+		t.Errorf("long listing: counts %v, want about 102 stats and 1 readlink", got)
+	}
+}
+
+func TestStatsOff(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a": ""})
+	if _, errOut, err := list(t, &Lister{}, dir); err != nil || errOut != "" {
+		t.Errorf("err = %v, stderr %q", err, errOut)
+	}
+}
+
+// makeTree creates a directory tree depth levels deep with width
+// subdirectories and files in each directory.
+func makeTree(tb testing.TB, dir string, depth, width int) {
+	for i := 0; i < width; i++ {
+		if err := os.WriteFile(filepath.Join(dir, "f"+strconv.Itoa(i)), nil, 0o644); err != nil {
+			tb.Fatal(err)
+		}
+		if depth > 1 {
+			sub := filepath.Join(dir, "d"+strconv.Itoa(i))
+			if err := os.Mkdir(sub, 0o755); err != nil {
+				tb.Fatal(err)
+			}
+			makeTree(tb, sub, depth-1, width)
+		}
+	}
+}
+
+func BenchmarkRecursive(b *testing.B) {
+	dir := b.TempDir()
+	makeTree(b, dir, 4, 6)
+	for _, bench := range []struct {
+		name   string
+		lister Lister
+	}{
+		{"plain", Lister{Recursive: true}},
+		{"long", Lister{Recursive: true, Format: FormatLong}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			var counts [5]int
+			for i := 0; i < b.N; i++ {
+				l := bench.lister
+				counts = listStats(b, &l, dir)
+			}
+			b.ReportMetric(float64(counts[2]), "stats/op")
+			b.ReportMetric(float64(counts[1]), "readdirs/op")
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package ls
+
+import "fmt"
+
+// A summary counts what a listing went through, for Lister.Summary.
+// It counts nothing unless on is set, since counting files stats them.
+type summary struct {
+	on          bool
+	dirs, files int
+	bytes       int64
+	// seen holds the files already counted, so a file reached through
+	// several hard links or followed symlinks is only counted once.
+	seen map[fileID]bool
+}
+
+// addEntries counts the files among entries. Directories are counted when
+// they are listed instead, and entries that cannot be stat'd not at all.
+func (s *summary) addEntries(entries []*entry) {
+	if !s.on {
+		return
+	}
+	for _, e := range entries {
+		if e.name == "." || e.name == ".." {
+			continue
+		}
+		info, err := e.stat()
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if stat, ok := sysStat(info); ok {
+			id := fileID{dev: stat.dev, ino: stat.ino}
+			if s.seen[id] {
+				continue
+			}
+			s.seen[id] = true
+		}
+		s.files++
+		s.bytes += info.Size()
+	}
+}
+
+// addDir counts a directory that was listed.
+func (s *summary) addDir() {
+	if s.on {
+		s.dirs++
+	}
+}
+
+func (s *summary) String() string {
+	return fmt.Sprintf("%d %s, %d %s, %d %s",
+		s.dirs, plural(s.dirs, "directory", "directories"),
+		s.files, plural(s.files, "file", "files"),
+		s.bytes, plural(int(s.bytes), "byte", "bytes"))
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
@@ -0,0 +1,41 @@
+package ls
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSummary(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a": "12345", "sub/b": "123", "sub/deeper/c": "1"})
+	if err := os.Link(filepath.Join(dir, "a"), filepath.Join(dir, "sub", "a-again")); err != nil {
+		t.Skipf("cannot create hard links: %v", err)
+	}
+
+	tests := []struct {
+		lister Lister
+		want   string
+	}{
+		{Lister{Summary: true}, "1 directory, 1 file, 5 bytes"},
+		// A file reached through two hard links is counted once.
+		{Lister{Summary: true, Recursive: true}, "3 directories, 3 files, 9 bytes"},
+		{Lister{Summary: true, Format: FormatTree, Recursive: true}, "3 directories, 3 files, 9 bytes"},
+	}
+	for _, tt := range tests {
+		got := lines(mustList(t, &tt.lister, dir))
+		if last := got[len(got)-1]; last != tt.want {
+			t.Errorf("format %d, Recursive=%v: got %q, want %q", tt.lister.Format, tt.lister.Recursive, last, tt.want)
+		}
+		if got[len(got)-2] != "" {
+			t.Errorf("no blank line before the summary in %q", got)
+		}
+	}
+
+	if out := mustList(t, &Lister{Summary: true}, t.TempDir()); !strings.HasSuffix(out, "\n1 directory, 0 files, 0 bytes\n") {
+		t.Errorf("empty directory: got %q", out)
+	}
+	if out := mustList(t, &Lister{Recursive: true}, dir); strings.Contains(out, "bytes") {
+		t.Errorf("summary written without Summary:\n%s", out)
+	}
+}
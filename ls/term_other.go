@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package ls
+
+import "os"
+
+// terminalColumns treats f as a non-terminal where there is no portable
+// way to ask for its size, so listings fall back to one name per line.
+func terminalColumns(f *os.File) (int, bool) {
+	return 0, false
+}
@@ -0,0 +1,25 @@
+//go:build linux || darwin
+
+package ls
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// terminalColumns asks the terminal driver for the width of f.
+func terminalColumns(f *os.File) (int, bool) {
+	var ws struct {
+		Row, Col, Xpixel, Ypixel uint16
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(),
+		syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 {
+		return 0, false
+	}
+	if ws.Col == 0 {
+		return 80, true
+	}
+	return int(ws.Col), true
+}
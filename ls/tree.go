@@ -0,0 +1,77 @@
+package ls
+
+import "fmt"
+
+// Connectors drawn before each name in FormatTree. The last child of a
+// directory gets the corner, and the lines below it are indented without
+// a rule.
+const (
+	treeBranch = "├── "
+	treeCorner = "└── "
+	treeRule   = "│   "
+	treeSpace  = "    "
+)
+
+// printTree writes entries, the arguments to List, with the contents of
+// directories drawn beneath them as a tree, like tree(1). With Recursive
+// the subdirectories are drawn too, down to MaxDepth.
+func (l *Lister) printTree(entries []*entry) {
+	cells := l.nameCells(entries, l.quoteNames(entries))
+	for i, e := range entries {
+		fmt.Fprint(l.out, cells[i].text)
+		l.endLine()
+		if e.mode.IsDir() && !l.Directory {
+			l.printSubtree(e.path, "", 1)
+		} else {
+			l.summary.addEntries(entries[i : i+1])
+		}
+	}
+}
+
+// printSubtree writes the contents of the directory path, found depth
+// levels down from an argument, with indent before each connector.
+func (l *Lister) printSubtree(path, indent string, depth int) {
+	dir, err := l.calls.open(path)
+	if err != nil {
+		l.reportPathError(statusSerious, "cannot open directory", err)
+		return
+	}
+	defer dir.Close()
+
+	leave, ok := l.enterDir(dir, path)
+	if !ok {
+		return
+	}
+	defer leave()
+
+	// Like tree -a, "." and ".." are never drawn, so -a shows what -A
+	// does.
+	entries := withoutDots(l.readEntries(dir, path))
+	l.summary.addDir()
+	l.summary.addEntries(entries)
+	recurse := l.Recursive && (l.MaxDepth == 0 || depth < l.MaxDepth)
+
+	cells := l.nameCells(entries, l.quoteNames(entries))
+	for i, e := range entries {
+		connector, next := treeBranch, treeRule
+		if i == len(entries)-1 {
+			connector, next = treeCorner, treeSpace
+		}
+		fmt.Fprint(l.out, indent+connector+cells[i].text)
+		l.endLine()
+		if recurse && l.descends(e) {
+			l.printSubtree(joinPath(path, e.name), indent+next, depth+1)
+		}
+	}
+}
+
+// withoutDots returns entries without "." and "..".
+func withoutDots(entries []*entry) []*entry {
+	var kept []*entry
+	for _, e := range entries {
+		if e.name != "." && e.name != ".." {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
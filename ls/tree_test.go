@@ -0,0 +1,49 @@
+package ls
+
+import "testing"
+
+func TestTree(t *testing.T) {
+	dir := makeFiles(t, map[string]string{"a/x": "", "a/y/z": "", "a/.h": "", "b": ""})
+	t.Run("flat", func(t *testing.T) {
+		want := dir + "\n" +
+			"├── a\n" +
+			"└── b\n"
+		if got := mustList(t, &Lister{Format: FormatTree}, dir); got != want {
+			t.Errorf("got\n%s\nwant\n%s", got, want)
+		}
+	})
+	t.Run("recursive", func(t *testing.T) {
+		want := dir + "\n" +
+			"├── a\n" +
+			"│   ├── x\n" +
+			"│   └── y\n" +
+			"│       └── z\n" +
+			"└── b\n"
+		if got := mustList(t, &Lister{Format: FormatTree, Recursive: true}, dir); got != want {
+			t.Errorf("got\n%s\nwant\n%s", got, want)
+		}
+	})
+	t.Run("max depth", func(t *testing.T) {
+		want := dir + "\n" +
+			"├── a\n" +
+			"│   ├── x\n" +
+			"│   └── y\n" +
+			"└── b\n"
+		if got := mustList(t, &Lister{Format: FormatTree, Recursive: true, MaxDepth: 2}, dir); got != want {
+			t.Errorf("got\n%s\nwant\n%s", got, want)
+		}
+	})
+	t.Run("all", func(t *testing.T) {
+		// "." and ".." are not drawn at any level.
+		want := dir + "\n" +
+			"├── a\n" +
+			"│   ├── .h\n" +
+			"│   ├── x\n" +
+			"│   └── y\n" +
+			"│       └── z\n" +
+			"└── b\n"
+		if got := mustList(t, &Lister{Format: FormatTree, Recursive: true, All: true}, dir); got != want {
+			t.Errorf("got\n%s\nwant\n%s", got, want)
+		}
+	})
+}
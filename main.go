@@ -1,261 +1,641 @@
 package main
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"os/user"
+	"runtime"
 	"strconv"
-	"syscall"
+	"strings"
 	"time"
+
+	"github.com/FinnTune/my-ls-1/ls"
 )
 
-var (
-	longListing   bool
-	recursive     bool
-	allFiles      bool
-	reverse       bool
-	sortByModTime bool
+// Exit statuses follow GNU ls: minor problems such as an entry that cannot
+// be stat'd exit 1, serious trouble such as a bad flag or an unreadable
+// directory exits 2.
+const (
+	exitMinor   = 1
+	exitSerious = 2
 )
 
 func main() {
-	parseFlags()
-
-	path := "." + string(os.PathSeparator)
-	if len(os.Args) > 1 {
-		if os.Args[1] != "-l" {
-			path = os.Args[1]
+	lister := &ls.Lister{
+		Out:       os.Stdout,
+		ColorSpec: os.Getenv("LS_COLORS"),
+		// Windows shells leave wildcards for the program to expand.
+		Glob:    runtime.GOOS == "windows",
+		TabSize: 8,
+	}
+	if tabSize := os.Getenv("TABSIZE"); tabSize != "" {
+		size, err := parseTabSize(tabSize)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "my-ls-1: ignoring invalid tab size in environment variable TABSIZE: '%s'\n", tabSize)
+		} else {
+			lister.TabSize = size
 		}
 	}
+	if columns := os.Getenv("COLUMNS"); columns != "" {
+		width, err := parseWidth(columns)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "my-ls-1: ignoring invalid width in environment variable COLUMNS: '%s'\n", columns)
+		} else {
+			lister.FallbackWidth = width
+		}
+	}
+	paths, fromStdin := parseFlags(lister)
+	// List falls back to "." without paths, but an empty --from-stdin
+	// list, such as from a find that matched nothing, lists nothing.
+	if fromStdin && len(paths) == 0 {
+		return
+	}
 
-	err := listFiles(path)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error listing files: %v\n", err)
-		os.Exit(1)
+	err := lister.List(paths)
+	switch {
+	case errors.Is(err, ls.ErrSerious):
+		os.Exit(exitSerious)
+	case errors.Is(err, ls.ErrMinor):
+		os.Exit(exitMinor)
 	}
 }
 
-func parseFlags() {
+// parseFlags applies the flags to lister and returns the paths to list,
+// reporting whether any were to be read from stdin.
+func parseFlags(lister *ls.Lister) ([]string, bool) {
+	var paths []string
+	fromStdin := false
 	args := os.Args[1:]
 	for i := 0; i < len(args); i++ {
 		arg := args[i]
-		switch arg {
-		case "-l":
-			longListing = true
-		case "-R":
-			recursive = true
-		case "-a":
-			allFiles = true
-		case "-r":
-			reverse = true
-		case "-t":
-			sortByModTime = true
-		default:
-			// Ignore non-flag arguments
+		if len(arg) < 2 || arg[0] != '-' {
+			paths = append(paths, arg)
+			continue
 		}
-	}
-}
-
-func listFiles(path string) error {
-	dir, err := os.Open(path)
-	if err != nil {
-		return err
-	}
-	defer dir.Close()
-
-	entries, err := dir.Readdirnames(-1)
-	if err != nil {
-		return err
-	}
-
-	if allFiles {
-		hiddenFiles, err := getHiddenFiles(path)
-		if err != nil {
-			return err
+		if arg == "--" {
+			// Everything after "--" is a path, even if it starts with "-".
+			paths = append(paths, args[i+1:]...)
+			break
 		}
-		entries = append(entries, hiddenFiles...)
-	}
-
-	if sortByModTime {
-		sortSliceByModTime(entries, path)
-	} else if reverse {
-		sortSliceReverse(entries)
-	}
-
-	for _, entry := range entries {
-		listFileDetails(path, entry)
-
-		if recursive {
-			subPath := path + string(os.PathSeparator) + entry
-			subInfo, err := os.Stat(subPath)
-			if err != nil {
-				return err
+		if arg == "--from-stdin" {
+			// The paths are read once every flag has been seen, so a bad
+			// flag is reported without waiting for input.
+			fromStdin = true
+			continue
+		}
+		if arg[1] == '-' {
+			if err := setLongFlag(lister, arg[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "my-ls-1: %v\n", err)
+				os.Exit(exitSerious)
 			}
-			if subInfo.IsDir() {
-				fmt.Printf("\n%s:\n", subPath)
-				err := listFiles(subPath)
-				if err != nil {
-					return err
+			continue
+		}
+		// Short flags may be bundled, so "-la" is the same as "-l -a".
+		// A flag that takes a value uses the rest of the word, or the
+		// next argument when the word ends with it, as in "-w80" or "-w 80".
+		for j, c := range arg[1:] {
+			if option, ok := valueFlags[c]; ok {
+				value := arg[2+j:]
+				if value == "" {
+					if i+1 == len(args) {
+						fmt.Fprintf(os.Stderr, "my-ls-1: option requires an argument -- '%c'\n", c)
+						os.Exit(exitSerious)
+					}
+					i++
+					value = args[i]
+				}
+				if err := setLongFlag(lister, option+"="+value); err != nil {
+					fmt.Fprintf(os.Stderr, "my-ls-1: %v\n", err)
+					os.Exit(exitSerious)
+				}
+				break
+			}
+			if option, ok := bsdAliases[c]; ok {
+				if err := setLongFlag(lister, option); err != nil {
+					fmt.Fprintf(os.Stderr, "my-ls-1: %v\n", err)
+					os.Exit(exitSerious)
 				}
+				continue
+			}
+			if !setShortFlag(lister, c) {
+				fmt.Fprintf(os.Stderr, "my-ls-1: invalid option -- '%c'\n", c)
+				os.Exit(exitSerious)
 			}
 		}
 	}
-
-	return nil
-}
-
-func getHiddenFiles(path string) ([]string, error) {
-	dir, err := os.Open(path)
-	if err != nil {
-		return nil, err
-	}
-	defer dir.Close()
-
-	allEntries, err := dir.Readdirnames(-1)
-	if err != nil {
-		return nil, err
+	if fromStdin {
+		paths = append(paths, readPaths(os.Stdin)...)
 	}
+	return paths, fromStdin
+}
 
-	var hiddenFiles []string
-	for _, entry := range allEntries {
-		if entry[0] == '.' {
-			hiddenFiles = append(hiddenFiles, entry)
+// readPaths reads one path per line from r for --from-stdin, skipping
+// empty lines. Unlike "-" in some tools this never conflicts with GNU ls,
+// which lists a file named "-".
+func readPaths(r io.Reader) []string {
+	var paths []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			paths = append(paths, line)
 		}
 	}
-
-	return hiddenFiles, nil
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "my-ls-1: reading standard input: %v\n", err)
+		os.Exit(exitSerious)
+	}
+	return paths
 }
 
-func getFileModTime(filePath string) (time.Time, error) {
-	fileInfo, err := os.Stat(filePath)
-	if err != nil {
-		return time.Time{}, err
-	}
-	sys := fileInfo.Sys().(*syscall.Stat_t)
-	return time.Unix(sys.Mtim.Sec, sys.Mtim.Nsec), nil
+// valueFlags maps the short flags that take a value to the long option
+// they stand for.
+var valueFlags = map[rune]string{
+	'w': "width",
+	'I': "ignore",
+	'T': "tabsize",
 }
 
-func sortSliceByModTime(slice []string, path string) {
-	customSort(slice, func(i, j int) bool {
-		timeI, errI := getFileModTime(path + string(os.PathSeparator) + slice[i])
-		timeJ, errJ := getFileModTime(path + string(os.PathSeparator) + slice[j])
+// bsdAliases maps short flags from BSD ls to the long option they stand
+// for, so users coming from macOS keep their habits. Where GNU ls gives a
+// letter another meaning, the BSD one wins. GNU reads -G as --no-group,
+// so ls -lG colors names here but leaves out the group column there, and
+// --no-group has only its long form.
+var bsdAliases = map[rune]string{
+	'G': "color=auto",
+}
 
-		if errI != nil || errJ != nil {
-			return slice[i] < slice[j]
+func setShortFlag(lister *ls.Lister, c rune) bool {
+	switch c {
+	case 'l':
+		lister.Format = ls.FormatLong
+	case 'R':
+		lister.Recursive = true
+	// As in GNU ls, the last of -a and -A wins.
+	case 'a':
+		lister.All = true
+		lister.AlmostAll = false
+	case 'A':
+		lister.AlmostAll = true
+		lister.All = false
+	case 'f':
+		// The fast path for huge directories: everything, unsorted and
+		// without anything that needs a stat. Like GNU ls it undoes
+		// earlier -l, -s and --color, but later flags still apply.
+		lister.All, lister.AlmostAll = true, false
+		lister.Sort = ls.SortNone
+		if lister.Format == ls.FormatLong {
+			lister.Format = ls.FormatDefault
 		}
-
-		if reverse {
-			return timeI.After(timeJ)
+		lister.Color = ls.ColorNever
+		lister.AllocatedSize = false
+	case 'r':
+		lister.Reverse = true
+	case 't':
+		lister.Sort = ls.SortTime
+	case 'U':
+		lister.Sort = ls.SortNone
+	case 'S':
+		lister.Sort = ls.SortSize
+	case 'X':
+		lister.Sort = ls.SortExtension
+	case 'v':
+		lister.Sort = ls.SortVersion
+	case 'h':
+		lister.HumanReadable = true
+		lister.BlockSize = ls.BlockSize{}
+	case 'k':
+		// Totals are already counted in 1K blocks, and as in GNU ls -k
+		// leaves the size column and any --block-size alone.
+	case '1':
+		// As in GNU ls, -1 never overrides -l.
+		if lister.Format != ls.FormatLong {
+			lister.Format = ls.FormatSingleColumn
 		}
-		return timeI.Before(timeJ)
-	})
-}
-
-func sortSliceReverse(slice []string) {
-	customSort(slice, func(i, j int) bool {
-		return slice[j] < slice[i]
-	})
+	case 'm':
+		lister.Format = ls.FormatCommas
+	case 'C':
+		lister.Format = ls.FormatColumns
+	case 'x':
+		lister.Format = ls.FormatAcross
+	case 'i':
+		lister.Inode = true
+	case 'F':
+		lister.Indicator = ls.IndicatorClassify
+	case 'p':
+		lister.Indicator = ls.IndicatorSlash
+	case 'c':
+		lister.Time = ls.TimeChange
+	case 'u':
+		lister.Time = ls.TimeAccess
+	case 'd':
+		lister.Directory = true
+	case 'Q':
+		lister.Quoting = ls.QuoteC
+	case 'b':
+		lister.Quoting = ls.QuoteEscape
+	case 'N':
+		lister.Quoting = ls.QuoteLiteral
+	case 'q':
+		lister.ControlChars = ls.ControlHide
+	case 's':
+		lister.AllocatedSize = true
+	case 'g':
+		lister.Format = ls.FormatLong
+		lister.NoOwner = true
+	case 'o':
+		lister.Format = ls.FormatLong
+		lister.NoGroup = true
+	case 'Z':
+		lister.SecurityContext = true
+	case 'L':
+		lister.Dereference = ls.DereferenceAll
+	case 'H':
+		lister.Dereference = ls.DereferenceArgs
+	case 'n':
+		lister.Format = ls.FormatLong
+		lister.NumericIDs = true
+	case '0':
+		setZero(lister)
+	default:
+		return false
+	}
+	return true
 }
 
-func customSort(slice []string, less func(i, j int) bool) {
-	n := len(slice)
-	for i := 0; i < n-1; i++ {
-		minIndex := i
-		for j := i + 1; j < n; j++ {
-			if less(j, minIndex) {
-				minIndex = j
-			}
+// setLongFlag applies a GNU-style long option given without its leading
+// dashes, such as "color=always".
+func setLongFlag(lister *ls.Lister, option string) error {
+	name, value, hasValue := strings.Cut(option, "=")
+	switch name {
+	case "color", "colour":
+		if !hasValue {
+			lister.Color = ls.ColorAlways
+			return nil
+		}
+		switch value {
+		case "always", "yes", "force":
+			lister.Color = ls.ColorAlways
+		case "auto", "tty", "if-tty":
+			lister.Color = ls.ColorAuto
+		case "never", "no", "none":
+			lister.Color = ls.ColorNever
+		default:
+			return fmt.Errorf("invalid argument '%s' for '--%s'", value, name)
+		}
+	case "all", "almost-all":
+		if hasValue {
+			return fmt.Errorf("option '--%s' doesn't allow an argument", name)
+		}
+		lister.All = name == "all"
+		lister.AlmostAll = !lister.All
+	case "sort":
+		if !hasValue {
+			return fmt.Errorf("option '--%s' requires an argument", name)
+		}
+		switch value {
+		case "none":
+			lister.Sort = ls.SortNone
+		case "time":
+			lister.Sort = ls.SortTime
+		case "size":
+			lister.Sort = ls.SortSize
+		case "extension":
+			lister.Sort = ls.SortExtension
+		case "version":
+			lister.Sort = ls.SortVersion
+		default:
+			return fmt.Errorf("invalid argument '%s' for '--%s'", value, name)
+		}
+	case "hide-control-chars", "show-control-chars":
+		if hasValue {
+			return fmt.Errorf("option '--%s' doesn't allow an argument", name)
+		}
+		lister.ControlChars = ls.ControlHide
+		if name == "show-control-chars" {
+			lister.ControlChars = ls.ControlShow
+		}
+	case "format":
+		if !hasValue {
+			return fmt.Errorf("option '--%s' requires an argument", name)
+		}
+		// Unlike -1, single-column replaces a long format.
+		switch value {
+		case "verbose", "long":
+			lister.Format = ls.FormatLong
+		case "single-column":
+			lister.Format = ls.FormatSingleColumn
+		case "vertical":
+			lister.Format = ls.FormatColumns
+		case "horizontal", "across":
+			lister.Format = ls.FormatAcross
+		case "commas":
+			lister.Format = ls.FormatCommas
+		default:
+			return fmt.Errorf("invalid argument '%s' for '--%s'", value, name)
+		}
+	case "quoting-style":
+		if !hasValue {
+			return fmt.Errorf("option '--%s' requires an argument", name)
+		}
+		switch value {
+		case "literal":
+			lister.Quoting = ls.QuoteLiteral
+		case "shell":
+			lister.Quoting = ls.QuoteShell
+		case "shell-always":
+			lister.Quoting = ls.QuoteShellAlways
+		case "shell-escape":
+			lister.Quoting = ls.QuoteShellEscape
+		case "shell-escape-always":
+			lister.Quoting = ls.QuoteShellEscapeAlways
+		case "c":
+			lister.Quoting = ls.QuoteC
+		case "escape":
+			lister.Quoting = ls.QuoteEscape
+		default:
+			return fmt.Errorf("invalid argument '%s' for '--%s'", value, name)
+		}
+	case "time-style":
+		switch value {
+		case "full-iso":
+			lister.TimeStyle = ls.TimeStyleFullISO
+		case "long-iso":
+			lister.TimeStyle = ls.TimeStyleLongISO
+		case "iso":
+			lister.TimeStyle = ls.TimeStyleISO
+		case "locale":
+			lister.TimeStyle = ls.TimeStyleLocale
+		default:
+			return fmt.Errorf("invalid argument '%s' for '--%s'", value, name)
+		}
+	case "size":
+		if hasValue {
+			return fmt.Errorf("option '--%s' doesn't allow an argument", name)
+		}
+		lister.AllocatedSize = true
+	case "context":
+		if hasValue {
+			return fmt.Errorf("option '--%s' doesn't allow an argument", name)
+		}
+		lister.SecurityContext = true
+	case "dereference", "dereference-command-line", "dereference-command-line-symlink-to-dir":
+		if hasValue {
+			return fmt.Errorf("option '--%s' doesn't allow an argument", name)
 		}
-		if minIndex != i {
-			slice[i], slice[minIndex] = slice[minIndex], slice[i]
+		switch name {
+		case "dereference":
+			lister.Dereference = ls.DereferenceAll
+		case "dereference-command-line":
+			lister.Dereference = ls.DereferenceArgs
+		default:
+			lister.Dereference = ls.DereferenceArgDirs
+		}
+	case "full-time":
+		if hasValue {
+			return fmt.Errorf("option '--%s' doesn't allow an argument", name)
+		}
+		lister.Format = ls.FormatLong
+		lister.TimeStyle = ls.TimeStyleFullISO
+	case "json":
+		if hasValue {
+			return fmt.Errorf("option '--%s' doesn't allow an argument", name)
+		}
+		lister.Format = ls.FormatJSON
+	case "tree":
+		if hasValue {
+			return fmt.Errorf("option '--%s' doesn't allow an argument", name)
+		}
+		lister.Format = ls.FormatTree
+		lister.Recursive = true
+	case "zero", "null":
+		if hasValue {
+			return fmt.Errorf("option '--%s' doesn't allow an argument", name)
+		}
+		setZero(lister)
+	case "glob":
+		if hasValue {
+			return fmt.Errorf("option '--%s' doesn't allow an argument", name)
+		}
+		lister.Glob = true
+	case "summary":
+		if hasValue {
+			return fmt.Errorf("option '--%s' doesn't allow an argument", name)
+		}
+		lister.Summary = true
+	case "icons":
+		if hasValue {
+			return fmt.Errorf("option '--%s' doesn't allow an argument", name)
+		}
+		lister.Icons = true
+	case "color-scale":
+		if hasValue {
+			return fmt.Errorf("option '--%s' doesn't allow an argument", name)
+		}
+		lister.ColorScale = true
+	case "no-group":
+		// Unlike -o this does not imply -l. GNU spells it -G too, but
+		// here -G keeps its BSD meaning.
+		if hasValue {
+			return fmt.Errorf("option '--%s' doesn't allow an argument", name)
+		}
+		lister.NoGroup = true
+	case "git":
+		if hasValue {
+			return fmt.Errorf("option '--%s' doesn't allow an argument", name)
+		}
+		lister.Git = true
+	case "stats":
+		// A diagnostic for slow filesystems, left out of the usual flags.
+		if hasValue {
+			return fmt.Errorf("option '--%s' doesn't allow an argument", name)
+		}
+		lister.Stats = true
+	case "from-stdin":
+		// A bare --from-stdin is handled by parseFlags.
+		return fmt.Errorf("option '--%s' doesn't allow an argument", name)
+	case "indicator-style":
+		if !hasValue {
+			return fmt.Errorf("option '--%s' requires an argument", name)
+		}
+		switch value {
+		case "none":
+			lister.Indicator = ls.IndicatorNone
+		case "slash":
+			lister.Indicator = ls.IndicatorSlash
+		case "file-type":
+			lister.Indicator = ls.IndicatorFileType
+		case "classify":
+			lister.Indicator = ls.IndicatorClassify
+		default:
+			return fmt.Errorf("invalid argument '%s' for '--%s'", value, name)
+		}
+	case "classify", "file-type":
+		if hasValue {
+			return fmt.Errorf("option '--%s' doesn't allow an argument", name)
+		}
+		lister.Indicator = ls.IndicatorClassify
+		if name == "file-type" {
+			lister.Indicator = ls.IndicatorFileType
 		}
+	case "group-directories-first", "group-directories-last":
+		if hasValue {
+			return fmt.Errorf("option '--%s' doesn't allow an argument", name)
+		}
+		lister.GroupDirectoriesFirst = name == "group-directories-first"
+		lister.GroupDirectoriesLast = !lister.GroupDirectoriesFirst
+	case "block-size":
+		if !hasValue {
+			return fmt.Errorf("option '--%s' requires an argument", name)
+		}
+		blockSize, err := ls.ParseBlockSize(value)
+		if err != nil {
+			return err
+		}
+		lister.HumanReadable = false
+		lister.BlockSize = blockSize
+	case "min-size":
+		if !hasValue {
+			return fmt.Errorf("option '--%s' requires an argument", name)
+		}
+		size, err := ls.ParseMinSize(value)
+		if err != nil {
+			return err
+		}
+		lister.MinSize = size
+	case "newer", "older":
+		if !hasValue {
+			return fmt.Errorf("option '--%s' requires an argument", name)
+		}
+		t, err := parseTimeSpec(value)
+		if err != nil {
+			return fmt.Errorf("invalid argument '%s' for '--%s'", value, name)
+		}
+		if name == "newer" {
+			lister.NewerThan = t
+		} else {
+			lister.OlderThan = t
+		}
+	case "newer-than":
+		if !hasValue {
+			return fmt.Errorf("option '--%s' requires an argument", name)
+		}
+		t, err := ls.ModTime(value)
+		if err != nil {
+			return err
+		}
+		lister.NewerThan = t
+	case "width":
+		if !hasValue {
+			return fmt.Errorf("option '--%s' requires an argument", name)
+		}
+		width, err := parseWidth(value)
+		if err != nil {
+			return err
+		}
+		lister.Width = width
+	case "width-percent":
+		if !hasValue {
+			return fmt.Errorf("option '--%s' requires an argument", name)
+		}
+		percent, err := strconv.Atoi(value)
+		if err != nil || percent < 1 || percent > 100 {
+			return fmt.Errorf("invalid width percentage: '%s'", value)
+		}
+		lister.WidthPercent = percent
+	case "ignore":
+		if !hasValue {
+			return fmt.Errorf("option '--%s' requires an argument", name)
+		}
+		lister.Ignore = append(lister.Ignore, value)
+	case "hide":
+		if !hasValue {
+			return fmt.Errorf("option '--%s' requires an argument", name)
+		}
+		lister.Hide = append(lister.Hide, value)
+	case "tabsize":
+		if !hasValue {
+			return fmt.Errorf("option '--%s' requires an argument", name)
+		}
+		tabSize, err := parseTabSize(value)
+		if err != nil {
+			return err
+		}
+		lister.TabSize = tabSize
+	case "max-depth":
+		if !hasValue {
+			return fmt.Errorf("option '--%s' requires an argument", name)
+		}
+		depth, err := strconv.Atoi(value)
+		if err != nil || depth < 0 {
+			return fmt.Errorf("invalid argument '%s' for '--%s'", value, name)
+		}
+		lister.MaxDepth = depth
+	default:
+		return fmt.Errorf("unrecognized option '--%s'", option)
 	}
+	return nil
 }
 
-func listFileDetails(path, entry string) {
-	fileInfo, err := os.Stat(path + string(os.PathSeparator) + entry)
-	if err != nil {
-		fmt.Println(err)
-		return
+// setZero applies -0 and --zero. As in GNU ls it also turns off color and
+// quoting, shows control characters as they are, and lists one entry per
+// line unless -l was given, though a later flag may turn them back on.
+func setZero(lister *ls.Lister) {
+	lister.Zero = true
+	if lister.Format != ls.FormatLong {
+		lister.Format = ls.FormatSingleColumn
 	}
-
-	mode := fileInfo.Mode()
-	uid := int(fileInfo.Sys().(*syscall.Stat_t).Uid)
-	gid := int(fileInfo.Sys().(*syscall.Stat_t).Gid)
-	size := fileInfo.Size()
-	modTime := fileInfo.ModTime().Format("Jan _2 15:04")
-	name := fileInfo.Name()
-
-	permissions := getPermissions(mode)
-	owner := getOwner(uid)
-	group := getGroup(gid)
-
-	fmt.Printf("%s %d %s %s %d %s %s\n", permissions, uid, owner, group, size, modTime, name)
+	lister.Color = ls.ColorNever
+	lister.Quoting = ls.QuoteLiteral
+	lister.ControlChars = ls.ControlShow
 }
 
-func getPermissions(mode os.FileMode) string {
-	const (
-		ownerRead  = 0400
-		ownerWrite = 0200
-		ownerExec  = 0100
-		groupRead  = 0040
-		groupWrite = 0020
-		groupExec  = 0010
-		otherRead  = 0004
-		otherWrite = 0002
-		otherExec  = 0001
-	)
-
-	perms := "---------"
-	if mode&ownerRead != 0 {
-		perms = setCharAt(perms, 0, 'r')
-	}
-	if mode&ownerWrite != 0 {
-		perms = setCharAt(perms, 1, 'w')
-	}
-	if mode&ownerExec != 0 {
-		perms = setCharAt(perms, 2, 'x')
-	}
-	if mode&groupRead != 0 {
-		perms = setCharAt(perms, 3, 'r')
+// parseTimeSpec parses a time for --newer or --older: either an age such
+// as "90s", "30m", "12h", "7d" or "2w", counted back from now, or an
+// RFC 3339 time.
+func parseTimeSpec(value string) (time.Time, error) {
+	units := map[byte]time.Duration{
+		's': time.Second,
+		'm': time.Minute,
+		'h': time.Hour,
+		'd': 24 * time.Hour,
+		'w': 7 * 24 * time.Hour,
 	}
-	if mode&groupWrite != 0 {
-		perms = setCharAt(perms, 4, 'w')
-	}
-	if mode&groupExec != 0 {
-		perms = setCharAt(perms, 5, 'x')
-	}
-	if mode&otherRead != 0 {
-		perms = setCharAt(perms, 6, 'r')
-	}
-	if mode&otherWrite != 0 {
-		perms = setCharAt(perms, 7, 'w')
-	}
-	if mode&otherExec != 0 {
-		perms = setCharAt(perms, 8, 'x')
+	if n := len(value); n > 1 {
+		if unit, ok := units[value[n-1]]; ok {
+			count, err := strconv.ParseUint(value[:n-1], 10, 32)
+			if err == nil {
+				return time.Now().Add(-time.Duration(count) * unit), nil
+			}
+		}
 	}
-
-	return perms
+	return time.Parse(time.RFC3339, value)
 }
 
-func setCharAt(str string, index int, char byte) string {
-	if index < 0 || index >= len(str) {
-		return str
+// parseTabSize parses a tab size for -T, --tabsize or TABSIZE.
+func parseTabSize(value string) (int, error) {
+	tabSize, err := strconv.Atoi(value)
+	if err != nil || tabSize < 0 {
+		return 0, fmt.Errorf("invalid tab size: '%s'", value)
 	}
-	return str[:index] + string(char) + str[index+1:]
+	return tabSize, nil
 }
 
-func getOwner(uid int) string {
-	user, err := user.LookupId(strconv.Itoa(uid))
-	if err != nil {
-		return strconv.Itoa(uid)
+// parseWidth parses a line width for -w, --width or COLUMNS. As in GNU ls,
+// 0 and values too large to represent mean no limit, which is returned as
+// -1 for Lister.Width.
+func parseWidth(value string) (int, error) {
+	width, err := strconv.Atoi(value)
+	switch {
+	case errors.Is(err, strconv.ErrRange) && value[0] != '-':
+		width = 0
+	case err != nil || width < 0:
+		return 0, fmt.Errorf("invalid line width: '%s'", value)
 	}
-	return user.Username
-}
 
-func getGroup(gid int) string {
-	group, err := user.LookupGroupId(strconv.Itoa(gid))
-	if err != nil {
-		return strconv.Itoa(gid)
+	if width == 0 {
+		return -1, nil
 	}
-	return group.Name
+	return width, nil
 }
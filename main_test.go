@@ -0,0 +1,306 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FinnTune/my-ls-1/ls"
+)
+
+// parse runs parseFlags on args as the command line and returns the
+// lister and paths it produced.
+func parse(t *testing.T, args ...string) (*ls.Lister, []string) {
+	t.Helper()
+	saved := os.Args
+	defer func() { os.Args = saved }()
+	os.Args = append([]string{"my-ls-1"}, args...)
+
+	lister := &ls.Lister{}
+	paths, _ := parseFlags(lister)
+	return lister, paths
+}
+
+func TestBundledFlags(t *testing.T) {
+	bundled, _ := parse(t, "-la", "-rt")
+	separate, _ := parse(t, "-l", "-a", "-r", "-t")
+	if !reflect.DeepEqual(bundled, separate) {
+		t.Errorf("-la -rt gave %+v, -l -a -r -t gave %+v", bundled, separate)
+	}
+	if bundled.Format != ls.FormatLong || !bundled.All || !bundled.Reverse || bundled.Sort != ls.SortTime || bundled.Recursive {
+		t.Errorf("got %+v", bundled)
+	}
+}
+
+func TestFlagPaths(t *testing.T) {
+	_, paths := parse(t, "a", "-l", "b", "--", "-l", "--all", "-")
+	if want := []string{"a", "b", "-l", "--all", "-"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("got %q, want %q", paths, want)
+	}
+}
+
+func TestValueFlags(t *testing.T) {
+	for _, args := range [][]string{{"-w40"}, {"-w", "40"}, {"--width=40"}, {"-lw40"}} {
+		lister, paths := parse(t, args...)
+		if lister.Width != 40 || len(paths) != 0 {
+			t.Errorf("%q: width %d, paths %q", args, lister.Width, paths)
+		}
+	}
+	lister, _ := parse(t, "-I", "*.o", "-I*.a", "--ignore=*.so", "-T0")
+	if want := []string{"*.o", "*.a", "*.so"}; !reflect.DeepEqual(lister.Ignore, want) {
+		t.Errorf("ignore %q, want %q", lister.Ignore, want)
+	}
+	if lister.TabSize != 0 {
+		t.Errorf("tab size %d", lister.TabSize)
+	}
+	if lister, _ := parse(t, "--tabsize=4"); lister.TabSize != 4 {
+		t.Errorf("--tabsize=4: tab size %d", lister.TabSize)
+	}
+	lister, _ = parse(t, "--hide=*.log", "--hide=*~")
+	if want := []string{"*.log", "*~"}; !reflect.DeepEqual(lister.Hide, want) {
+		t.Errorf("hide %q, want %q", lister.Hide, want)
+	}
+}
+
+func TestShortFlags(t *testing.T) {
+	tests := []struct {
+		args []string
+		want ls.Lister
+	}{
+		{[]string{"-A"}, ls.Lister{AlmostAll: true}},
+		{[]string{"-S"}, ls.Lister{Sort: ls.SortSize}},
+		{[]string{"-X"}, ls.Lister{Sort: ls.SortExtension}},
+		{[]string{"-v"}, ls.Lister{Sort: ls.SortVersion}},
+		{[]string{"-h"}, ls.Lister{HumanReadable: true}},
+		{[]string{"-1i"}, ls.Lister{Format: ls.FormatSingleColumn, Inode: true}},
+		// -1 never overrides -l, in either order.
+		{[]string{"-l", "-1"}, ls.Lister{Format: ls.FormatLong}},
+		{[]string{"-1", "-l"}, ls.Lister{Format: ls.FormatLong}},
+		{[]string{"-m1"}, ls.Lister{Format: ls.FormatSingleColumn}},
+		{[]string{"-1m"}, ls.Lister{Format: ls.FormatCommas}},
+		{[]string{"-C"}, ls.Lister{Format: ls.FormatColumns}},
+		{[]string{"-lC"}, ls.Lister{Format: ls.FormatColumns}},
+		{[]string{"-x"}, ls.Lister{Format: ls.FormatAcross}},
+		{[]string{"-Cx"}, ls.Lister{Format: ls.FormatAcross}},
+		{[]string{"-Cl"}, ls.Lister{Format: ls.FormatLong}},
+		{[]string{"-lm"}, ls.Lister{Format: ls.FormatCommas}},
+		{[]string{"-ml"}, ls.Lister{Format: ls.FormatLong}},
+		{[]string{"-G"}, ls.Lister{Color: ls.ColorAuto}},
+		{[]string{"-lG"}, ls.Lister{Format: ls.FormatLong, Color: ls.ColorAuto}},
+		{[]string{"-aA"}, ls.Lister{AlmostAll: true}},
+		{[]string{"-Aa"}, ls.Lister{All: true}},
+		{[]string{"--all"}, ls.Lister{All: true}},
+		{[]string{"-a", "--almost-all"}, ls.Lister{AlmostAll: true}},
+		{[]string{"--almost-all", "-a"}, ls.Lister{All: true}},
+		{[]string{"-l", "--color", "-f"}, ls.Lister{All: true, Sort: ls.SortNone}},
+		{[]string{"-f", "-l"}, ls.Lister{All: true, Sort: ls.SortNone, Format: ls.FormatLong}},
+		{[]string{"-U"}, ls.Lister{Sort: ls.SortNone}},
+		{[]string{"-U", "-t", "-S"}, ls.Lister{Sort: ls.SortSize}},
+		{[]string{"--sort=time", "-U"}, ls.Lister{Sort: ls.SortNone}},
+		{[]string{"-c"}, ls.Lister{Time: ls.TimeChange}},
+		{[]string{"-cu"}, ls.Lister{Time: ls.TimeAccess}},
+		{[]string{"-d"}, ls.Lister{Directory: true}},
+		{[]string{"-Q"}, ls.Lister{Quoting: ls.QuoteC}},
+		{[]string{"-N"}, ls.Lister{Quoting: ls.QuoteLiteral}},
+		{[]string{"-QN"}, ls.Lister{Quoting: ls.QuoteLiteral}},
+		{[]string{"-q"}, ls.Lister{ControlChars: ls.ControlHide}},
+		{[]string{"-q", "--show-control-chars"}, ls.Lister{ControlChars: ls.ControlShow}},
+		{[]string{"--hide-control-chars"}, ls.Lister{ControlChars: ls.ControlHide}},
+		{[]string{"-Qb"}, ls.Lister{Quoting: ls.QuoteEscape}},
+		{[]string{"-n"}, ls.Lister{Format: ls.FormatLong, NumericIDs: true}},
+		{[]string{"-Z"}, ls.Lister{SecurityContext: true}},
+		{[]string{"--context"}, ls.Lister{SecurityContext: true}},
+		{[]string{"-l", "--zero"}, ls.Lister{Format: ls.FormatLong, Zero: true, Quoting: ls.QuoteLiteral, ControlChars: ls.ControlShow}},
+		{[]string{"--color", "-C", "-0"}, ls.Lister{Format: ls.FormatSingleColumn, Zero: true, Quoting: ls.QuoteLiteral, ControlChars: ls.ControlShow}},
+		{[]string{"-0", "--color"}, ls.Lister{Format: ls.FormatSingleColumn, Zero: true, Quoting: ls.QuoteLiteral, ControlChars: ls.ControlShow, Color: ls.ColorAlways}},
+		{[]string{"-L"}, ls.Lister{Dereference: ls.DereferenceAll}},
+		{[]string{"-LH"}, ls.Lister{Dereference: ls.DereferenceArgs}},
+		{[]string{"--dereference"}, ls.Lister{Dereference: ls.DereferenceAll}},
+		{[]string{"--dereference-command-line"}, ls.Lister{Dereference: ls.DereferenceArgs}},
+		{[]string{"--dereference-command-line-symlink-to-dir"}, ls.Lister{Dereference: ls.DereferenceArgDirs}},
+		{[]string{"-L", "--dereference-command-line-symlink-to-dir"}, ls.Lister{Dereference: ls.DereferenceArgDirs}},
+		{[]string{"-g"}, ls.Lister{Format: ls.FormatLong, NoOwner: true}},
+		{[]string{"-o"}, ls.Lister{Format: ls.FormatLong, NoGroup: true}},
+		{[]string{"-s"}, ls.Lister{AllocatedSize: true}},
+		{[]string{"--size"}, ls.Lister{AllocatedSize: true}},
+		{[]string{"-F"}, ls.Lister{Indicator: ls.IndicatorClassify}},
+		{[]string{"-Fp"}, ls.Lister{Indicator: ls.IndicatorSlash}},
+		{[]string{"--indicator-style=slash"}, ls.Lister{Indicator: ls.IndicatorSlash}},
+		{[]string{"-F", "--indicator-style=none"}, ls.Lister{}},
+		{[]string{"--file-type"}, ls.Lister{Indicator: ls.IndicatorFileType}},
+		{[]string{"--classify"}, ls.Lister{Indicator: ls.IndicatorClassify}},
+		{[]string{"--color"}, ls.Lister{Color: ls.ColorAlways}},
+		{[]string{"--colour=auto"}, ls.Lister{Color: ls.ColorAuto}},
+		{[]string{"--color=always", "--color=never"}, ls.Lister{}},
+		{[]string{"--json"}, ls.Lister{Format: ls.FormatJSON}},
+		{[]string{"--icons"}, ls.Lister{Icons: true}},
+		{[]string{"--summary"}, ls.Lister{Summary: true}},
+		{[]string{"--color-scale"}, ls.Lister{ColorScale: true}},
+		{[]string{"--git"}, ls.Lister{Git: true}},
+		{[]string{"--no-group"}, ls.Lister{NoGroup: true}},
+		{[]string{"--width-percent=50"}, ls.Lister{WidthPercent: 50}},
+		{[]string{"-l", "--format=single-column"}, ls.Lister{Format: ls.FormatSingleColumn}},
+		{[]string{"--format=commas", "-l"}, ls.Lister{Format: ls.FormatLong}},
+		{[]string{"--stats"}, ls.Lister{Stats: true}},
+		{[]string{"--glob"}, ls.Lister{Glob: true}},
+		{[]string{"--group-directories-first"}, ls.Lister{GroupDirectoriesFirst: true}},
+		{[]string{"--group-directories-first", "--group-directories-last"}, ls.Lister{GroupDirectoriesLast: true}},
+		{[]string{"--group-directories-last", "--group-directories-first"}, ls.Lister{GroupDirectoriesFirst: true}},
+		{[]string{"--max-depth=2"}, ls.Lister{MaxDepth: 2}},
+		{[]string{"--block-size=K"}, ls.Lister{BlockSize: ls.BlockSize{Bytes: 1024, Suffix: "K"}}},
+		{[]string{"--block-size=K", "-h"}, ls.Lister{HumanReadable: true}},
+		{[]string{"-h", "--block-size=1M", "-k"}, ls.Lister{BlockSize: ls.BlockSize{Bytes: 1 << 20}}},
+		{[]string{"--min-size=1K"}, ls.Lister{MinSize: 1024}},
+		{[]string{"--time-style=long-iso"}, ls.Lister{TimeStyle: ls.TimeStyleLongISO}},
+		{[]string{"--tree"}, ls.Lister{Format: ls.FormatTree, Recursive: true}},
+		{[]string{"--full-time"}, ls.Lister{Format: ls.FormatLong, TimeStyle: ls.TimeStyleFullISO}},
+	}
+	for _, tt := range tests {
+		if got, _ := parse(t, tt.args...); !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("%q: got %+v, want %+v", tt.args, *got, tt.want)
+		}
+	}
+}
+
+func TestLongFlagWords(t *testing.T) {
+	sorts := map[string]ls.SortKey{
+		"none": ls.SortNone, "time": ls.SortTime, "size": ls.SortSize,
+		"extension": ls.SortExtension, "version": ls.SortVersion,
+	}
+	for word, want := range sorts {
+		var lister ls.Lister
+		if err := setLongFlag(&lister, "sort="+word); err != nil || lister.Sort != want {
+			t.Errorf("--sort=%s: %v, sort %d", word, err, lister.Sort)
+		}
+	}
+	formats := map[string]ls.Format{
+		"long": ls.FormatLong, "verbose": ls.FormatLong, "single-column": ls.FormatSingleColumn,
+		"vertical": ls.FormatColumns, "horizontal": ls.FormatAcross, "across": ls.FormatAcross,
+		"commas": ls.FormatCommas,
+	}
+	for word, want := range formats {
+		var lister ls.Lister
+		if err := setLongFlag(&lister, "format="+word); err != nil || lister.Format != want {
+			t.Errorf("--format=%s: %v, format %d", word, err, lister.Format)
+		}
+	}
+	quoting := map[string]ls.QuotingStyle{
+		"literal": ls.QuoteLiteral, "shell": ls.QuoteShell, "shell-always": ls.QuoteShellAlways,
+		"shell-escape": ls.QuoteShellEscape, "shell-escape-always": ls.QuoteShellEscapeAlways,
+		"c": ls.QuoteC, "escape": ls.QuoteEscape,
+	}
+	for word, want := range quoting {
+		var lister ls.Lister
+		if err := setLongFlag(&lister, "quoting-style="+word); err != nil || lister.Quoting != want {
+			t.Errorf("--quoting-style=%s: %v, quoting %d", word, err, lister.Quoting)
+		}
+	}
+}
+
+func TestLongFlagErrors(t *testing.T) {
+	tests := []struct{ option, want string }{
+		{"sort=name", "invalid argument 'name' for '--sort'"},
+		{"sort", "option '--sort' requires an argument"},
+		{"format=grid", "invalid argument 'grid' for '--format'"},
+		{"color=sometimes", "invalid argument 'sometimes' for '--color'"},
+		{"indicator-style=star", "invalid argument 'star' for '--indicator-style'"},
+		{"time-style=posix", "invalid argument 'posix' for '--time-style'"},
+		{"group-directories-first=yes", "option '--group-directories-first' doesn't allow an argument"},
+		{"max-depth", "option '--max-depth' requires an argument"},
+		{"max-depth=-1", "invalid argument '-1' for '--max-depth'"},
+		{"block-size=1X", "invalid suffix in --block-size argument '1X'"},
+		{"min-size", "option '--min-size' requires an argument"},
+		{"min-size=1X", "invalid suffix in --min-size argument '1X'"},
+		{"newer=yesterday", "invalid argument 'yesterday' for '--newer'"},
+		{"newer-than=/nonexistent/ref", "cannot access '/nonexistent/ref': No such file or directory"},
+		{"width=abc", "invalid line width: 'abc'"},
+		{"from-stdin=x", "option '--from-stdin' doesn't allow an argument"},
+		{"width-percent=0", "invalid width percentage: '0'"},
+		{"width-percent=101", "invalid width percentage: '101'"},
+		{"tabsize=-1", "invalid tab size: '-1'"},
+		{"all=yes", "option '--all' doesn't allow an argument"},
+		{"quoting-style=perl", "invalid argument 'perl' for '--quoting-style'"},
+		{"bogus", "unrecognized option '--bogus'"},
+	}
+	for _, tt := range tests {
+		var lister ls.Lister
+		if err := setLongFlag(&lister, tt.option); err == nil || err.Error() != tt.want {
+			t.Errorf("--%s: error %v, want %q", tt.option, err, tt.want)
+		}
+	}
+}
+
+func TestSetShortFlagRejectsUnknown(t *testing.T) {
+	var lister ls.Lister
+	for _, c := range "eyz" {
+		if setShortFlag(&lister, c) {
+			t.Errorf("-%c was accepted", c)
+		}
+	}
+}
+
+func TestParseWidth(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"80", 80},
+		{"1", 1},
+		{"0", -1},
+		{"99999999999999999999", -1},
+	}
+	for _, tt := range tests {
+		if got, err := parseWidth(tt.value); err != nil || got != tt.want {
+			t.Errorf("parseWidth(%q) = %d, %v, want %d", tt.value, got, err, tt.want)
+		}
+	}
+	for _, value := range []string{"-1", "abc", "-99999999999999999999", "8O"} {
+		if _, err := parseWidth(value); err == nil {
+			t.Errorf("parseWidth(%q) succeeded", value)
+		}
+	}
+}
+
+func TestReadPaths(t *testing.T) {
+	got := readPaths(strings.NewReader("a\n\nb c\n-l\nlast"))
+	if want := []string{"a", "b c", "-l", "last"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := readPaths(strings.NewReader("")); len(got) != 0 {
+		t.Errorf("empty input gave %q", got)
+	}
+}
+
+func TestParseTimeSpec(t *testing.T) {
+	tests := []struct {
+		value string
+		age   time.Duration
+	}{
+		{"90s", 90 * time.Second},
+		{"30m", 30 * time.Minute},
+		{"12h", 12 * time.Hour},
+		{"7d", 7 * 24 * time.Hour},
+		{"2w", 14 * 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		got, err := parseTimeSpec(tt.value)
+		if err != nil {
+			t.Errorf("parseTimeSpec(%q): %v", tt.value, err)
+			continue
+		}
+		if age := time.Since(got); age < tt.age || age > tt.age+time.Minute {
+			t.Errorf("parseTimeSpec(%q) is %v ago, want %v", tt.value, age, tt.age)
+		}
+	}
+	got, err := parseTimeSpec("2024-01-02T03:04:05Z")
+	if want := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC); err != nil || !got.Equal(want) {
+		t.Errorf("parseTimeSpec(RFC 3339) = %v, %v, want %v", got, err, want)
+	}
+	for _, value := range []string{"", "d", "-5d", "5y", "yesterday"} {
+		if _, err := parseTimeSpec(value); err == nil {
+			t.Errorf("parseTimeSpec(%q) succeeded", value)
+		}
+	}
+}